@@ -0,0 +1,106 @@
+// Command templatecheck validates a directory of templates before deploying it.
+//
+// It parses every template file, reports all the errors with their file and line, lists the defined
+// templates, and optionally executes templates against sample binding data read from a JSON file:
+//
+//	templatecheck -dir templates -ext .tmpl,.html -samples samples.json
+//
+// The samples file contains a list of samples:
+//
+//	[{"template": "pages/home", "layout": "layout", "binding": {"Title": "Home"}}]
+//
+// The command exits with status 1 when a problem is found.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+type sample struct {
+	Template string      `json:"template"`
+	Layout   string      `json:"layout"`
+	Binding  interface{} `json:"binding"`
+}
+
+func main() {
+	directory := flag.String("dir", "templates", "directory of the templates")
+	extensions := flag.String("ext", ".tmpl", "comma separated extensions of the template files")
+	left := flag.String("left", "", "left delimiter, defaults to {{")
+	right := flag.String("right", "", "right delimiter, defaults to }}")
+	functions := flag.String("funcs", "", "comma separated names of the functions used by the templates")
+	standard := flag.Bool("std", false, "make the standard functions of render.Functions available")
+	samplesFile := flag.String("samples", "", "JSON file of samples to execute")
+	quiet := flag.Bool("q", false, "do not list the defined templates")
+	flag.Parse()
+
+	funcs := []template.FuncMap{placeholders(*functions)}
+	if *standard {
+		funcs = append([]template.FuncMap{render.Functions()}, funcs...)
+	}
+	factory := render.NewDiskTemplateFactory().
+		SetDirectory(*directory).
+		SetExtensions(strings.Split(*extensions, ",")...).
+		SetDelimiters(*left, *right).
+		SetFunctions(funcs...)
+
+	samples, err := readSamples(*samplesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := factory.Validate(samples...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if !*quiet {
+		for _, name := range report.Templates {
+			fmt.Println(name)
+		}
+	}
+	if !report.OK() {
+		fmt.Fprint(os.Stderr, report)
+		os.Exit(1)
+	}
+}
+
+// placeholders defines the functions so that the templates using them can be parsed.
+// They accept any argument and return an empty string.
+func placeholders(names string) template.FuncMap {
+	functions := template.FuncMap{}
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			functions[name] = func(...interface{}) string { return "" }
+		}
+	}
+	return functions
+}
+
+func readSamples(file string) ([]render.TemplateSample, error) {
+	if file == "" {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var samples []sample
+	if err := json.Unmarshal(content, &samples); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	result := make([]render.TemplateSample, len(samples))
+	for i, sample := range samples {
+		result[i] = render.TemplateSample{Template: sample.Template, Layout: sample.Layout, Binding: sample.Binding}
+	}
+	return result, nil
+}
@@ -0,0 +1,15 @@
+module github.com/deliverous/cocktails
+
+go 1.21
+
+require (
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	golang.org/x/text v0.21.0
+	google.golang.org/protobuf v1.36.5
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
@@ -0,0 +1,304 @@
+// Package i18n translates the messages of an application: it loads message catalogs from JSON or PO files,
+// negotiates the locale of each request, and provides the translation functions to the templates.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Catalog holds the translated messages of several locales.
+//
+// A message has one form, or several plural forms chosen by the plural rule of its locale. A message missing
+// from a locale is looked up in the fallbacks of the locale, then in its parent locale ("fr" for "fr-CA"),
+// and finally in the default locale. A message missing everywhere is its own translation.
+type Catalog struct {
+	// DefaultLocale is the last fallback, and the locale of the requests that accept none of the locales.
+	DefaultLocale string
+
+	mutex     sync.RWMutex
+	messages  map[string]map[string][]string
+	rules     map[string]PluralRule
+	fallbacks map[string][]string
+}
+
+// NewCatalog creates an empty catalog.
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		DefaultLocale: Canonical(defaultLocale),
+		messages:      make(map[string]map[string][]string),
+		rules:         make(map[string]PluralRule),
+		fallbacks:     make(map[string][]string),
+	}
+}
+
+// SetFallbacks defines the locales in which the messages missing from locale are looked up first.
+func (catalog *Catalog) SetFallbacks(locale string, fallbacks ...string) *Catalog {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	canonicals := make([]string, len(fallbacks))
+	for i, fallback := range fallbacks {
+		canonicals[i] = Canonical(fallback)
+	}
+	catalog.fallbacks[Canonical(locale)] = canonicals
+	return catalog
+}
+
+// SetPluralRule defines the plural rule of locale, replacing the built-in rule of its language or the rule of
+// its PO file.
+func (catalog *Catalog) SetPluralRule(locale string, rule PluralRule) *Catalog {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	catalog.rules[Canonical(locale)] = rule
+	return catalog
+}
+
+// Add adds a message to locale: its translation, or its plural forms in the order of the plural rule.
+func (catalog *Catalog) Add(locale string, key string, forms ...string) *Catalog {
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	catalog.add(Canonical(locale), key, forms)
+	return catalog
+}
+
+func (catalog *Catalog) add(locale string, key string, forms []string) {
+	messages, ok := catalog.messages[locale]
+	if !ok {
+		messages = make(map[string][]string)
+		catalog.messages[locale] = messages
+	}
+	messages[key] = forms
+}
+
+// LoadJSON adds the messages of a JSON object to locale. The values are translations, or arrays of plural forms:
+//
+//	{"Hello %s": "Bonjour %s", "%d apples": ["%d pomme", "%d pommes"]}
+func (catalog *Catalog) LoadJSON(locale string, reader io.Reader) error {
+	var messages map[string]interface{}
+	if err := json.NewDecoder(reader).Decode(&messages); err != nil {
+		return fmt.Errorf("i18n: %s: %v", locale, err)
+	}
+
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	locale = Canonical(locale)
+	for key, value := range messages {
+		switch value := value.(type) {
+		case string:
+			catalog.add(locale, key, []string{value})
+		case []interface{}:
+			forms := make([]string, len(value))
+			for i, form := range value {
+				var ok bool
+				if forms[i], ok = form.(string); !ok {
+					return fmt.Errorf("i18n: %s: plural forms of %q must be strings", locale, key)
+				}
+			}
+			catalog.add(locale, key, forms)
+		default:
+			return fmt.Errorf("i18n: %s: message %q must be a string or an array of strings", locale, key)
+		}
+	}
+	return nil
+}
+
+// LoadFS loads the catalogs found in the directory of the file system: the files named after their locale,
+// with the extension .json or .po, like fr-CA.json or pt_BR.po. The other files are ignored.
+func (catalog *Catalog) LoadFS(fileSystem fs.FS, directory string) error {
+	entries, err := fs.ReadDir(fileSystem, directory)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := path.Ext(entry.Name())
+		if ext != ".json" && ext != ".po" {
+			continue
+		}
+		if err := catalog.loadFile(fileSystem, path.Join(directory, entry.Name()), strings.TrimSuffix(entry.Name(), ext), ext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (catalog *Catalog) loadFile(fileSystem fs.FS, file string, locale string, ext string) error {
+	reader, err := fileSystem.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if ext == ".po" {
+		return catalog.LoadPO(locale, reader)
+	}
+	return catalog.LoadJSON(locale, reader)
+}
+
+// Locales returns the locales having messages, and the default locale, sorted.
+func (catalog *Catalog) Locales() []string {
+	catalog.mutex.RLock()
+	defer catalog.mutex.RUnlock()
+	locales := []string{catalog.DefaultLocale}
+	for locale := range catalog.messages {
+		if locale != catalog.DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Translate returns the translation of the message key in locale. If there are arguments, the translation is
+// used as a format for them, like with fmt.Sprintf.
+func (catalog *Catalog) Translate(locale string, key string, args ...interface{}) string {
+	forms, _ := catalog.lookup(locale, key)
+	if len(args) == 0 {
+		return forms[0]
+	}
+	return fmt.Sprintf(forms[0], args...)
+}
+
+// Plural returns the plural form of the message key for the count n in locale, used as a format for the
+// arguments, or for n if there are no arguments.
+func (catalog *Catalog) Plural(locale string, key string, n int, args ...interface{}) string {
+	forms, found := catalog.lookup(locale, key)
+	index := 0
+	if found != "" {
+		index = catalog.rule(found)(n)
+	} else if n != 1 {
+		index = 1
+	}
+	if index < 0 || index >= len(forms) {
+		index = len(forms) - 1
+	}
+	if len(args) == 0 {
+		args = []interface{}{n}
+	}
+	return fmt.Sprintf(forms[index], args...)
+}
+
+// lookup returns the forms of the message and the locale in which it has been found, or the key itself and
+// an empty locale.
+func (catalog *Catalog) lookup(locale string, key string) ([]string, string) {
+	catalog.mutex.RLock()
+	defer catalog.mutex.RUnlock()
+	for _, candidate := range catalog.chain(Canonical(locale)) {
+		if forms, ok := catalog.messages[candidate][key]; ok && len(forms) > 0 {
+			return forms, candidate
+		}
+	}
+	return []string{key}, ""
+}
+
+// chain returns the locales in which a message of locale is looked up, in order.
+func (catalog *Catalog) chain(locale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	var visit func(string)
+	visit = func(locale string) {
+		if locale == "" || seen[locale] {
+			return
+		}
+		seen[locale] = true
+		chain = append(chain, locale)
+		for _, fallback := range catalog.fallbacks[locale] {
+			visit(fallback)
+		}
+		if index := strings.LastIndex(locale, "-"); index > 0 {
+			visit(locale[:index])
+		}
+	}
+	visit(locale)
+	visit(catalog.DefaultLocale)
+	return chain
+}
+
+// rule returns the plural rule of locale: the one defined for it, or for its parent locale, or the built-in
+// rule of its language.
+func (catalog *Catalog) rule(locale string) PluralRule {
+	catalog.mutex.RLock()
+	defer catalog.mutex.RUnlock()
+	for candidate := locale; candidate != ""; {
+		if rule, ok := catalog.rules[candidate]; ok {
+			return rule
+		}
+		index := strings.LastIndex(candidate, "-")
+		if index < 0 {
+			break
+		}
+		candidate = candidate[:index]
+	}
+	return builtinRule(locale)
+}
+
+// Match returns the locale of the catalog best matching the preferred locales, in order of preference, or the
+// default locale if none matches. A preferred locale matches the same locale, then its parent locale, then
+// any locale of the same language.
+func (catalog *Catalog) Match(preferences ...string) string {
+	locales := catalog.Locales()
+	for _, preference := range preferences {
+		if locale := match(locales, Canonical(preference)); locale != "" {
+			return locale
+		}
+	}
+	return catalog.DefaultLocale
+}
+
+func match(locales []string, preference string) string {
+	if preference == "" {
+		return ""
+	}
+	for candidate := preference; ; {
+		for _, locale := range locales {
+			if locale == candidate {
+				return locale
+			}
+		}
+		index := strings.LastIndex(candidate, "-")
+		if index < 0 {
+			break
+		}
+		candidate = candidate[:index]
+	}
+	for _, locale := range locales {
+		if language(locale) == language(preference) {
+			return locale
+		}
+	}
+	return ""
+}
+
+// language returns the language part of a canonical locale.
+func language(locale string) string {
+	if index := strings.Index(locale, "-"); index >= 0 {
+		return locale[:index]
+	}
+	return locale
+}
+
+// Canonical returns the canonical form of a locale: subtags separated by hyphens, the language in lower case,
+// the script in title case and the region in upper case, like "en", "fr-CA" or "zh-Hant-TW".
+func Canonical(locale string) string {
+	subtags := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for i, subtag := range subtags {
+		switch {
+		case i == 0:
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 2:
+			subtags[i] = strings.ToUpper(subtag)
+		case len(subtag) == 4:
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		default:
+			subtags[i] = strings.ToLower(subtag)
+		}
+	}
+	return strings.Join(subtags, "-")
+}
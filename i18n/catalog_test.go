@@ -0,0 +1,134 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func frenchCatalog() *Catalog {
+	return NewCatalog("en").
+		Add("en", "Hello %s", "Hello %s").
+		Add("en", "Goodbye", "Goodbye").
+		Add("en", "%d apples", "%d apple", "%d apples").
+		Add("fr", "Hello %s", "Bonjour %s").
+		Add("fr", "%d apples", "%d pomme", "%d pommes").
+		Add("fr-CA", "Goodbye", "Bonsoir")
+}
+
+func Test_Catalog_Translate_ShouldUseTheLocale(t *testing.T) {
+	catalog := frenchCatalog()
+	expect(t, catalog.Translate("en", "Hello %s", "Bob"), "Hello Bob")
+	expect(t, catalog.Translate("fr", "Hello %s", "Bob"), "Bonjour Bob")
+	expect(t, catalog.Translate("fr-CA", "Goodbye"), "Bonsoir")
+}
+
+func Test_Catalog_Translate_ShouldFallBackToTheParentThenToTheDefaultLocale(t *testing.T) {
+	catalog := frenchCatalog()
+	expect(t, catalog.Translate("fr_ca", "Hello %s", "Bob"), "Bonjour Bob")
+	expect(t, catalog.Translate("fr", "Goodbye"), "Goodbye")
+	expect(t, catalog.Translate("de", "Hello %s", "Bob"), "Hello Bob")
+}
+
+func Test_Catalog_Translate_ShouldUseTheFallbacks(t *testing.T) {
+	catalog := frenchCatalog().SetFallbacks("fr-BE", "fr-CA")
+	expect(t, catalog.Translate("fr-BE", "Goodbye"), "Bonsoir")
+	expect(t, catalog.Translate("fr-BE", "Hello %s", "Bob"), "Bonjour Bob")
+}
+
+func Test_Catalog_Translate_WhenMissing_ShouldUseTheKey(t *testing.T) {
+	catalog := frenchCatalog()
+	expect(t, catalog.Translate("fr", "Missing"), "Missing")
+	expect(t, catalog.Translate("fr", "Missing %d", 42), "Missing 42")
+}
+
+func Test_Catalog_Plural_ShouldUseThePluralRuleOfTheLocale(t *testing.T) {
+	catalog := frenchCatalog()
+	expect(t, catalog.Plural("en", "%d apples", 0), "0 apples")
+	expect(t, catalog.Plural("en", "%d apples", 1), "1 apple")
+	expect(t, catalog.Plural("en", "%d apples", 2), "2 apples")
+	expect(t, catalog.Plural("fr", "%d apples", 0), "0 pomme")
+	expect(t, catalog.Plural("fr", "%d apples", 1), "1 pomme")
+	expect(t, catalog.Plural("fr-CA", "%d apples", 2), "2 pommes")
+}
+
+func Test_Catalog_Plural_WithArguments_ShouldFormatTheArguments(t *testing.T) {
+	catalog := NewCatalog("en").Add("en", "%[2]s has %[1]d apples", "%[2]s has an apple", "%[2]s has %[1]d apples")
+	expect(t, catalog.Plural("en", "%[2]s has %[1]d apples", 1, 1, "Bob"), "Bob has an apple")
+	expect(t, catalog.Plural("en", "%[2]s has %[1]d apples", 3, 3, "Bob"), "Bob has 3 apples")
+}
+
+func Test_Catalog_Plural_WhenMissing_ShouldUseTheKey(t *testing.T) {
+	expect(t, NewCatalog("en").Plural("en", "%d apples", 2), "2 apples")
+}
+
+func Test_Catalog_Plural_WithMoreFormsThanTheMessage_ShouldUseTheLastForm(t *testing.T) {
+	catalog := NewCatalog("en").Add("ru", "%d files", "%d файл")
+	expect(t, catalog.Plural("ru", "%d files", 5), "5 файл")
+}
+
+func Test_Catalog_SetPluralRule_ShouldReplaceTheBuiltinRule(t *testing.T) {
+	catalog := frenchCatalog().SetPluralRule("fr", func(n int) int { return 1 })
+	expect(t, catalog.Plural("fr-CA", "%d apples", 1), "1 pommes")
+}
+
+func Test_Catalog_LoadJSON_ShouldAddTheMessages(t *testing.T) {
+	catalog := NewCatalog("en")
+	err := catalog.LoadJSON("fr", strings.NewReader(`{"Hello %s": "Bonjour %s", "%d apples": ["%d pomme", "%d pommes"]}`))
+	expect(t, err, nil)
+	expect(t, catalog.Translate("fr", "Hello %s", "Bob"), "Bonjour Bob")
+	expect(t, catalog.Plural("fr", "%d apples", 3), "3 pommes")
+}
+
+func Test_Catalog_LoadJSON_WithInvalidMessages_ShouldFail(t *testing.T) {
+	refute(t, NewCatalog("en").LoadJSON("fr", strings.NewReader(`{"Hello": 42}`)), nil)
+	refute(t, NewCatalog("en").LoadJSON("fr", strings.NewReader(`{"Hello": [42]}`)), nil)
+	refute(t, NewCatalog("en").LoadJSON("fr", strings.NewReader(`["Hello"]`)), nil)
+}
+
+func Test_Catalog_LoadFS_ShouldLoadTheFilesNamedAfterTheLocales(t *testing.T) {
+	catalog := NewCatalog("en")
+	err := catalog.LoadFS(fstest.MapFS{
+		"locales/fr.json":   {Data: []byte(`{"Hello": "Bonjour"}`)},
+		"locales/pt_BR.po":  {Data: []byte("msgid \"Hello\"\nmsgstr \"Olá\"\n")},
+		"locales/README.md": {Data: []byte(`Translations`)},
+	}, "locales")
+	expect(t, err, nil)
+	expect(t, strings.Join(catalog.Locales(), ","), "en,fr,pt-BR")
+	expect(t, catalog.Translate("fr", "Hello"), "Bonjour")
+	expect(t, catalog.Translate("pt-BR", "Hello"), "Olá")
+}
+
+func Test_Catalog_Match_ShouldChooseTheBestLocale(t *testing.T) {
+	catalog := frenchCatalog().Add("pt-BR", "Hello %s", "Olá %s")
+	expect(t, catalog.Match("fr-CA"), "fr-CA")
+	expect(t, catalog.Match("fr-BE"), "fr")
+	expect(t, catalog.Match("pt-PT"), "pt-BR")
+	expect(t, catalog.Match("de", "fr"), "fr")
+	expect(t, catalog.Match("de"), "en")
+	expect(t, catalog.Match(), "en")
+}
+
+func Test_Canonical(t *testing.T) {
+	expect(t, Canonical("EN"), "en")
+	expect(t, Canonical("fr_ca"), "fr-CA")
+	expect(t, Canonical("zh-hant-tw"), "zh-Hant-TW")
+	expect(t, Canonical(""), "")
+}
+
+func Test_ParsePluralRule(t *testing.T) {
+	russian, err := ParsePluralRule("(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)")
+	expect(t, err, nil)
+	for n, index := range map[int]int{1: 0, 2: 1, 4: 1, 5: 2, 11: 2, 12: 2, 21: 0, 22: 1, 25: 2, 111: 2} {
+		expect(t, russian(n), index)
+	}
+
+	arithmetic, _ := ParsePluralRule("!(n - 2 * 3 / 2 + 1 < 0) ? n % 0 : 7")
+	expect(t, arithmetic(1), 7)
+	expect(t, arithmetic(5), 0)
+
+	for _, invalid := range []string{"", "n ==", "(n", "n ? 1", "m", "n 1"} {
+		_, err := ParsePluralRule(invalid)
+		refute(t, err, nil)
+	}
+}
@@ -0,0 +1,172 @@
+package i18n
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type localeKey struct{}
+
+// WithLocale returns a shallow copy of the request whose context holds the locale.
+func WithLocale(request *http.Request, locale string) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), localeKey{}, locale))
+}
+
+// Locale returns the locale stored in the context of the request by the Negotiator, or an empty string.
+func Locale(request *http.Request) string {
+	locale, _ := request.Context().Value(localeKey{}).(string)
+	return locale
+}
+
+// Negotiator is a middleware which chooses the locale of each request among the locales of a catalog, and
+// stores it in the request context, see Locale. In order of priority, the locale is taken from the query
+// parameter, the cookie, then the Accept-Language header. The values that do not match a locale of the
+// catalog are ignored, and the default locale of the catalog is used when nothing matches.
+//
+// The Vary header of the responses includes Accept-Language, and Cookie when CookieName is set, so that the
+// caches do not serve a response in another locale. The query parameter needs nothing, being part of the URL.
+type Negotiator struct {
+	Catalog *Catalog
+	// QueryParameter is the query parameter overriding the locale. Default is "lang", empty to disable.
+	QueryParameter string
+	// CookieName is the cookie overriding the locale. Default is "lang", empty to disable.
+	CookieName string
+	// ContentLanguage defines if the Content-Language response header is set to the locale. Default is true.
+	ContentLanguage bool
+}
+
+// NewNegotiator creates a new Negotiator with default values.
+func NewNegotiator(catalog *Catalog) *Negotiator {
+	return &Negotiator{
+		Catalog:         catalog,
+		QueryParameter:  "lang",
+		CookieName:      "lang",
+		ContentLanguage: true,
+	}
+}
+
+func (m *Negotiator) SetQueryParameter(name string) *Negotiator {
+	m.QueryParameter = name
+	return m
+}
+
+func (m *Negotiator) SetCookieName(name string) *Negotiator {
+	m.CookieName = name
+	return m
+}
+
+func (m *Negotiator) SetContentLanguage(value bool) *Negotiator {
+	m.ContentLanguage = value
+	return m
+}
+
+// Negotiate is the Middleware function to use in the chain.
+func (m *Negotiator) Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		locale := m.negotiate(request)
+		writer.Header().Add("Vary", "Accept-Language")
+		if m.CookieName != "" {
+			writer.Header().Add("Vary", "Cookie")
+		}
+		if m.ContentLanguage {
+			writer.Header().Set("Content-Language", locale)
+		}
+		next.ServeHTTP(writer, WithLocale(request, locale))
+	})
+}
+
+func (m *Negotiator) negotiate(request *http.Request) string {
+	locales := m.Catalog.Locales()
+	if m.QueryParameter != "" {
+		if locale := match(locales, Canonical(request.URL.Query().Get(m.QueryParameter))); locale != "" {
+			return locale
+		}
+	}
+	if m.CookieName != "" {
+		if cookie, err := request.Cookie(m.CookieName); err == nil {
+			if locale := match(locales, Canonical(cookie.Value)); locale != "" {
+				return locale
+			}
+		}
+	}
+	return m.Catalog.Match(ParseAcceptLanguage(request.Header.Get("Accept-Language"))...)
+}
+
+// ParseAcceptLanguage returns the languages of an Accept-Language header, by decreasing quality.
+// The languages with a quality of 0, and the wildcard, are omitted.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		language string
+		quality  float64
+	}
+	var languages []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		language := strings.TrimSpace(fields[0])
+		if language == "" || language == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, parameter := range fields[1:] {
+			parameter = strings.TrimSpace(parameter)
+			if strings.HasPrefix(parameter, "q=") {
+				value, err := strconv.ParseFloat(parameter[2:], 64)
+				if err != nil {
+					value = 0
+				}
+				quality = value
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, weighted{language, quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+
+	result := make([]string, len(languages))
+	for i, language := range languages {
+		result[i] = language.language
+	}
+	return result
+}
+
+// Functions returns the translation functions for locale:
+//
+//	{{ T "Hello %s" .Name }}        the translation of a message, see Catalog.Translate
+//	{{ plural "%d apples" .Count }} the plural form of a message, see Catalog.Plural
+//	{{ locale }}                    the locale
+//
+// Give the functions for the default locale to the template factory, and RequestFunctions to the TemplateRender,
+// so that each rendering uses the locale of its request.
+func (catalog *Catalog) Functions(locale string) template.FuncMap {
+	return template.FuncMap{
+		"T": func(key string, args ...interface{}) string {
+			return catalog.Translate(locale, key, args...)
+		},
+		"plural": func(key string, n int, args ...interface{}) string {
+			return catalog.Plural(locale, key, n, args...)
+		},
+		"locale": func() string {
+			return locale
+		},
+	}
+}
+
+// RequestFunctions returns the translation functions for the locale of the request, or for the default locale
+// if the request has no locale. It matches the render.RequestFunctions type:
+//
+//	render.NewTemplateRender().SetRequestFunctions(catalog.RequestFunctions)
+func (catalog *Catalog) RequestFunctions(request *http.Request) template.FuncMap {
+	locale := ""
+	if request != nil {
+		locale = Locale(request)
+	}
+	if locale == "" {
+		locale = catalog.DefaultLocale
+	}
+	return catalog.Functions(locale)
+}
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+func negotiate(negotiator *Negotiator, request *http.Request) (string, *httptest.ResponseRecorder) {
+	locale := ""
+	handler := negotiator.Negotiate(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		locale = Locale(request)
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return locale, recorder
+}
+
+func negotiatorRequest(target string, acceptLanguage string, cookie string) *http.Request {
+	request := httptest.NewRequest("GET", target, nil)
+	if acceptLanguage != "" {
+		request.Header.Set("Accept-Language", acceptLanguage)
+	}
+	if cookie != "" {
+		request.AddCookie(&http.Cookie{Name: "lang", Value: cookie})
+	}
+	return request
+}
+
+func Test_Negotiator_ShouldUseTheAcceptLanguageHeader(t *testing.T) {
+	negotiator := NewNegotiator(frenchCatalog())
+	locale, recorder := negotiate(negotiator, negotiatorRequest("/", "de;q=0.9, fr-CA;q=0.5, en;q=0.1", ""))
+	expect(t, locale, "fr-CA")
+	expect(t, recorder.Header().Get("Content-Language"), "fr-CA")
+	expect(t, recorder.Header().Get("Vary"), "Accept-Language")
+
+	locale, _ = negotiate(negotiator, negotiatorRequest("/", "fr-BE", ""))
+	expect(t, locale, "fr")
+}
+
+func Test_Negotiator_WithoutMatchingLanguage_ShouldUseTheDefaultLocale(t *testing.T) {
+	locale, _ := negotiate(NewNegotiator(frenchCatalog()), negotiatorRequest("/", "de, it", ""))
+	expect(t, locale, "en")
+	locale, _ = negotiate(NewNegotiator(frenchCatalog()), negotiatorRequest("/", "", ""))
+	expect(t, locale, "en")
+}
+
+func Test_Negotiator_ShouldPreferTheCookieThenTheQueryParameter(t *testing.T) {
+	negotiator := NewNegotiator(frenchCatalog())
+	locale, _ := negotiate(negotiator, negotiatorRequest("/", "en", "fr"))
+	expect(t, locale, "fr")
+	locale, _ = negotiate(negotiator, negotiatorRequest("/?lang=fr-CA", "en", "fr"))
+	expect(t, locale, "fr-CA")
+	locale, _ = negotiate(negotiator, negotiatorRequest("/?lang=xx", "en", "yy"))
+	expect(t, locale, "en")
+}
+
+func Test_Negotiator_WithDisabledOverrides_ShouldUseTheHeader(t *testing.T) {
+	negotiator := NewNegotiator(frenchCatalog()).SetQueryParameter("").SetCookieName("").SetContentLanguage(false)
+	locale, recorder := negotiate(negotiator, negotiatorRequest("/?lang=fr", "en", "fr"))
+	expect(t, locale, "en")
+	expect(t, recorder.Header().Get("Content-Language"), "")
+}
+
+func Test_Negotiator_ShouldVaryOnTheCookie(t *testing.T) {
+	_, recorder := negotiate(NewNegotiator(frenchCatalog()), negotiatorRequest("/", "fr", ""))
+	expect(t, strings.Join(recorder.Header().Values("Vary"), ", "), "Accept-Language, Cookie")
+	_, recorder = negotiate(NewNegotiator(frenchCatalog()).SetCookieName(""), negotiatorRequest("/", "fr", ""))
+	expect(t, strings.Join(recorder.Header().Values("Vary"), ", "), "Accept-Language")
+}
+
+func Test_ParseAcceptLanguage_ShouldSortByQuality(t *testing.T) {
+	expect(t, strings.Join(ParseAcceptLanguage("fr;q=0.5, en-US, *;q=0.1, de;q=0, it;q=0.8, es;q=0.5"), ","), "en-US,it,fr,es")
+	expect(t, len(ParseAcceptLanguage("")), 0)
+	expect(t, len(ParseAcceptLanguage("fr;q=abc")), 0)
+}
+
+func Test_Catalog_Functions_ShouldTranslateInTemplates(t *testing.T) {
+	tmpl := template.Must(template.New("t").Funcs(frenchCatalog().Functions("fr")).Parse(
+		`{{ locale }}: {{ T "Hello %s" "<Bob>" }}, {{ plural "%d apples" 2 }}`))
+	out := new(strings.Builder)
+	expect(t, tmpl.Execute(out, nil), nil)
+	expect(t, out.String(), "fr: Bonjour &lt;Bob&gt;, 2 pommes")
+}
+
+func Test_Catalog_RequestFunctions_ShouldTranslateEachRenderingInTheLocaleOfItsRequest(t *testing.T) {
+	catalog := frenchCatalog()
+	factory := render.NewStaticTemplateFactory("i18n").
+		SetFunctions(catalog.Functions(catalog.DefaultLocale)).
+		SetSubTemplates(func() (string, string) { return "hello", `{{ T "Hello %s" . }}` })
+	renderer := render.NewTemplateRender().SetFactory(factory).SetRequestFunctions(catalog.RequestFunctions)
+	handler := NewNegotiator(catalog).Negotiate(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		renderer.RenderRequest(writer, request, http.StatusOK, "hello", "Bob")
+	}))
+
+	for acceptLanguage, expected := range map[string]string{"fr": "Bonjour Bob", "en": "Hello Bob", "": "Hello Bob"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, negotiatorRequest("/", acceptLanguage, ""))
+		expect(t, recorder.Body.String(), expected)
+	}
+
+	recorder := httptest.NewRecorder()
+	renderer.Render(recorder, http.StatusOK, "hello", "Bob")
+	expect(t, recorder.Body.String(), "Hello Bob")
+}
@@ -0,0 +1,241 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PluralRule returns the index of the plural form to use for the count n.
+type PluralRule func(n int) int
+
+// builtinRules are the gettext plural expressions of the common languages. The other languages use the
+// English rule.
+var builtinRules = map[string]string{
+	"fr": "n > 1",
+	"pt": "n > 1",
+	"ja": "0",
+	"ko": "0",
+	"zh": "0",
+	"vi": "0",
+	"th": "0",
+	"id": "0",
+	"ru": "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"uk": "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"be": "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"sr": "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"hr": "n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"pl": "n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2",
+	"cs": "n==1 ? 0 : n>=2 && n<=4 ? 1 : 2",
+	"sk": "n==1 ? 0 : n>=2 && n<=4 ? 1 : 2",
+}
+
+// englishRule is the rule of the languages with a singular and a plural form, the singular being used for 1.
+func englishRule(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+func builtinRule(locale string) PluralRule {
+	if expression, ok := builtinRules[language(locale)]; ok {
+		if rule, err := ParsePluralRule(expression); err == nil {
+			return rule
+		}
+	}
+	return englishRule
+}
+
+// ParsePluralRule parses the plural expression of a gettext Plural-Forms header, like "n != 1" or
+// "(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)". The expression uses the C operators,
+// with the integer n as the only variable.
+func ParsePluralRule(expression string) (PluralRule, error) {
+	parser := &pluralParser{input: expression}
+	parser.next()
+	evaluate, err := parser.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if parser.token != "" {
+		return nil, fmt.Errorf("i18n: unexpected %q in plural expression %q", parser.token, expression)
+	}
+	return PluralRule(evaluate), nil
+}
+
+// pluralParser is a recursive descent parser of plural expressions, producing closures.
+type pluralParser struct {
+	input string
+	token string
+}
+
+// next reads the next token, empty at the end of the input.
+func (parser *pluralParser) next() {
+	parser.input = strings.TrimLeft(parser.input, " \t\r\n")
+	if parser.input == "" {
+		parser.token = ""
+		return
+	}
+	length := 1
+	switch c := parser.input[0]; {
+	case c >= '0' && c <= '9':
+		for length < len(parser.input) && parser.input[length] >= '0' && parser.input[length] <= '9' {
+			length++
+		}
+	case strings.HasPrefix(parser.input, "&&"), strings.HasPrefix(parser.input, "||"),
+		strings.HasPrefix(parser.input, "=="), strings.HasPrefix(parser.input, "!="),
+		strings.HasPrefix(parser.input, "<="), strings.HasPrefix(parser.input, ">="):
+		length = 2
+	}
+	parser.token, parser.input = parser.input[:length], parser.input[length:]
+}
+
+type pluralExpression func(n int) int
+
+func (parser *pluralParser) ternary() (pluralExpression, error) {
+	condition, err := parser.binary(0)
+	if err != nil || parser.token != "?" {
+		return condition, err
+	}
+	parser.next()
+	then, err := parser.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if parser.token != ":" {
+		return nil, fmt.Errorf("i18n: expected : in plural expression, got %q", parser.token)
+	}
+	parser.next()
+	otherwise, err := parser.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if condition(n) != 0 {
+			return then(n)
+		}
+		return otherwise(n)
+	}, nil
+}
+
+// pluralOperators are the binary operators, by increasing precedence.
+var pluralOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (parser *pluralParser) binary(level int) (pluralExpression, error) {
+	if level == len(pluralOperators) {
+		return parser.unary()
+	}
+	left, err := parser.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for contains(pluralOperators[level], parser.token) {
+		operator := parser.token
+		parser.next()
+		right, err := parser.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = combine(operator, left, right)
+	}
+	return left, nil
+}
+
+func (parser *pluralParser) unary() (pluralExpression, error) {
+	switch token := parser.token; {
+	case token == "!":
+		parser.next()
+		operand, err := parser.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int { return boolean(operand(n) == 0) }, nil
+	case token == "(":
+		parser.next()
+		expression, err := parser.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if parser.token != ")" {
+			return nil, fmt.Errorf("i18n: expected ) in plural expression, got %q", parser.token)
+		}
+		parser.next()
+		return expression, nil
+	case token == "n":
+		parser.next()
+		return func(n int) int { return n }, nil
+	case token != "" && token[0] >= '0' && token[0] <= '9':
+		value, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, err
+		}
+		parser.next()
+		return func(int) int { return value }, nil
+	default:
+		return nil, fmt.Errorf("i18n: unexpected %q in plural expression", token)
+	}
+}
+
+func combine(operator string, left pluralExpression, right pluralExpression) pluralExpression {
+	switch operator {
+	case "||":
+		return func(n int) int { return boolean(left(n) != 0 || right(n) != 0) }
+	case "&&":
+		return func(n int) int { return boolean(left(n) != 0 && right(n) != 0) }
+	case "==":
+		return func(n int) int { return boolean(left(n) == right(n)) }
+	case "!=":
+		return func(n int) int { return boolean(left(n) != right(n)) }
+	case "<":
+		return func(n int) int { return boolean(left(n) < right(n)) }
+	case "<=":
+		return func(n int) int { return boolean(left(n) <= right(n)) }
+	case ">":
+		return func(n int) int { return boolean(left(n) > right(n)) }
+	case ">=":
+		return func(n int) int { return boolean(left(n) >= right(n)) }
+	case "+":
+		return func(n int) int { return left(n) + right(n) }
+	case "-":
+		return func(n int) int { return left(n) - right(n) }
+	case "*":
+		return func(n int) int { return left(n) * right(n) }
+	case "/":
+		return func(n int) int {
+			if divisor := right(n); divisor != 0 {
+				return left(n) / divisor
+			}
+			return 0
+		}
+	default:
+		return func(n int) int {
+			if divisor := right(n); divisor != 0 {
+				return left(n) % divisor
+			}
+			return 0
+		}
+	}
+}
+
+func boolean(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package i18n
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// poEntry is an entry of a PO file.
+type poEntry struct {
+	context    string
+	id         string
+	plural     string
+	forms      []string
+	fuzzy      bool
+	translated bool
+	// continued is the field continued by the lines made of a string only.
+	continued func(string)
+}
+
+// LoadPO adds the messages of a gettext PO file to locale. The plural rule of the locale is read from the
+// Plural-Forms header, unless a rule has already been defined with SetPluralRule. The fuzzy and untranslated
+// entries are ignored. A message with a context is keyed by the context and the message separated by "\x04",
+// as gettext does.
+func (catalog *Catalog) LoadPO(locale string, reader io.Reader) error {
+	entries, err := readPO(reader)
+	if err != nil {
+		return fmt.Errorf("i18n: %s: %v", locale, err)
+	}
+
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+	locale = Canonical(locale)
+	for _, entry := range entries {
+		if entry.id == "" && entry.context == "" {
+			if err := catalog.loadPOHeader(locale, entry.forms[0]); err != nil {
+				return fmt.Errorf("i18n: %s: %v", locale, err)
+			}
+			continue
+		}
+		if entry.fuzzy || len(entry.forms) == 0 || entry.forms[0] == "" {
+			continue
+		}
+		key := entry.id
+		if entry.context != "" {
+			key = entry.context + "\x04" + entry.id
+		}
+		catalog.add(locale, key, entry.forms)
+	}
+	return nil
+}
+
+// loadPOHeader defines the plural rule of the locale from the Plural-Forms header, like
+// "Plural-Forms: nplurals=2; plural=(n > 1);".
+func (catalog *Catalog) loadPOHeader(locale string, header string) error {
+	if _, ok := catalog.rules[locale]; ok {
+		return nil
+	}
+	for _, line := range strings.Split(header, "\n") {
+		if !strings.HasPrefix(line, "Plural-Forms:") {
+			continue
+		}
+		for _, field := range strings.Split(strings.TrimPrefix(line, "Plural-Forms:"), ";") {
+			if field = strings.TrimSpace(field); strings.HasPrefix(field, "plural=") {
+				rule, err := ParsePluralRule(strings.TrimPrefix(field, "plural="))
+				if err != nil {
+					return err
+				}
+				catalog.rules[locale] = rule
+			}
+		}
+	}
+	return nil
+}
+
+// readPO reads the entries of a PO file.
+func readPO(reader io.Reader) ([]*poEntry, error) {
+	var entries []*poEntry
+	entry := &poEntry{}
+	flush := func() {
+		if entry.translated {
+			entries = append(entries, entry)
+		}
+		entry = &poEntry{}
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "#"):
+			if entry.translated {
+				flush()
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				entry.fuzzy = true
+			}
+		case strings.HasPrefix(line, `"`):
+			value, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+			if entry.continued == nil {
+				return nil, fmt.Errorf("line %d: string without keyword", number)
+			}
+			entry.continued(value)
+		default:
+			keyword, value, err := poKeyword(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+			if entry.translated && (keyword == "msgctxt" || keyword == "msgid") {
+				flush()
+			}
+			if err := entry.set(keyword, value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", number, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+// poKeyword splits a line like `msgstr[1] "value"` into its keyword and its unquoted value.
+func poKeyword(line string) (string, string, error) {
+	index := strings.IndexAny(line, " \t")
+	if index < 0 {
+		return "", "", fmt.Errorf("invalid line %q", line)
+	}
+	value, err := strconv.Unquote(strings.TrimSpace(line[index:]))
+	if err != nil {
+		return "", "", err
+	}
+	return line[:index], value, nil
+}
+
+func (entry *poEntry) set(keyword string, value string) error {
+	index := 0
+	switch {
+	case keyword == "msgctxt":
+		entry.context = value
+		entry.continued = func(value string) { entry.context += value }
+		return nil
+	case keyword == "msgid":
+		entry.id = value
+		entry.continued = func(value string) { entry.id += value }
+		return nil
+	case keyword == "msgid_plural":
+		entry.plural = value
+		entry.continued = func(value string) { entry.plural += value }
+		return nil
+	case keyword == "msgstr":
+	case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+		var err error
+		if index, err = strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1]); err != nil || index < 0 || index > 16 {
+			return fmt.Errorf("invalid keyword %q", keyword)
+		}
+	default:
+		return fmt.Errorf("unknown keyword %q", keyword)
+	}
+
+	for len(entry.forms) <= index {
+		entry.forms = append(entry.forms, "")
+	}
+	entry.forms[index] = value
+	entry.translated = true
+	entry.continued = func(value string) { entry.forms[index] += value }
+	return nil
+}
@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+const frenchPO = `# French translations
+msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+#: home.tmpl:3
+msgid "Hello %s"
+msgstr "Bonjour %s"
+
+msgid ""
+"A long "
+"message"
+msgstr "Un long "
+"message"
+
+msgid "%d apple"
+msgid_plural "%d apples"
+msgstr[0] "%d pomme"
+msgstr[1] "%d pommes"
+
+#, fuzzy
+msgid "Goodbye"
+msgstr "Au revoir"
+
+msgid "Untranslated"
+msgstr ""
+
+msgctxt "menu"
+msgid "File"
+msgstr "Fichier"
+msgid "Escaped"
+msgstr "\"quoted\"\ttab"
+`
+
+func Test_Catalog_LoadPO_ShouldAddTheMessages(t *testing.T) {
+	catalog := NewCatalog("en")
+	err := catalog.LoadPO("fr", strings.NewReader(frenchPO))
+	expect(t, err, nil)
+	expect(t, catalog.Translate("fr", "Hello %s", "Bob"), "Bonjour Bob")
+	expect(t, catalog.Translate("fr", "A long message"), "Un long message")
+	expect(t, catalog.Translate("fr", "menu\x04File"), "Fichier")
+	expect(t, catalog.Translate("fr", "Escaped"), "\"quoted\"\ttab")
+}
+
+func Test_Catalog_LoadPO_ShouldIgnoreTheFuzzyAndUntranslatedEntries(t *testing.T) {
+	catalog := NewCatalog("en")
+	catalog.LoadPO("fr", strings.NewReader(frenchPO))
+	expect(t, catalog.Translate("fr", "Goodbye"), "Goodbye")
+	expect(t, catalog.Translate("fr", "Untranslated"), "Untranslated")
+}
+
+func Test_Catalog_LoadPO_ShouldUseThePluralFormsHeader(t *testing.T) {
+	catalog := NewCatalog("en")
+	catalog.LoadPO("fr", strings.NewReader(frenchPO))
+	expect(t, catalog.Plural("fr", "%d apple", 0), "0 pomme")
+	expect(t, catalog.Plural("fr", "%d apple", 2), "2 pommes")
+
+	catalog = NewCatalog("en").SetPluralRule("fr", func(n int) int { return 1 })
+	catalog.LoadPO("fr", strings.NewReader(frenchPO))
+	expect(t, catalog.Plural("fr", "%d apple", 0), "0 pommes")
+}
+
+func Test_Catalog_LoadPO_WithInvalidFile_ShouldFail(t *testing.T) {
+	for _, content := range []string{
+		`"orphan string"`,
+		`msgid "unterminated`,
+		`msgunknown "value"`,
+		`msgstr[x] "value"`,
+		"msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n >;\\n\"",
+	} {
+		refute(t, NewCatalog("en").LoadPO("fr", strings.NewReader(content)), nil)
+	}
+}
@@ -0,0 +1,25 @@
+// Package cspnonce stores the Content-Security-Policy nonce of a request. It is shared by the middlewares, which
+// generate the nonces, and the render package, which gives them to the templates, without one depending on the
+// other.
+package cspnonce
+
+import (
+	"context"
+	"net/http"
+)
+
+type key struct{}
+
+// With returns a copy of the request holding the nonce.
+func With(request *http.Request, nonce string) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), key{}, nonce))
+}
+
+// Get returns the nonce of the request, empty if none or if the request is nil.
+func Get(request *http.Request) string {
+	if request == nil {
+		return ""
+	}
+	nonce, _ := request.Context().Value(key{}).(string)
+	return nonce
+}
@@ -0,0 +1,26 @@
+// Package media holds the media type helpers shared by the render package and the middlewares.
+package media
+
+import "strings"
+
+// IsTextual returns true for the text/* media types and for the JSON, XML, JavaScript and form based media
+// types, whose content is text in a charset. The parameters of the content type are ignored.
+func IsTextual(contentType string) bool {
+	mediatype := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	parts := strings.SplitN(mediatype, "/", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	if parts[0] == "text" {
+		return true
+	}
+	subtype := parts[1]
+	if strings.HasSuffix(subtype, "+json") || strings.HasSuffix(subtype, "+xml") {
+		return true
+	}
+	switch subtype {
+	case "json", "xml", "javascript", "ecmascript", "x-javascript", "ndjson", "x-ndjson", "jsonl", "x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package media
+
+import "testing"
+
+func Test_IsTextual_ShouldRecognizeTheTextualMediaTypes(t *testing.T) {
+	for contentType, expected := range map[string]bool{
+		"text/plain":                        true,
+		"Text/HTML; charset=ISO-8859-1":     true,
+		"application/json":                  true,
+		"application/problem+json":          true,
+		"image/svg+xml":                     true,
+		"application/javascript":            true,
+		"application/x-ndjson":              true,
+		"application/x-www-form-urlencoded": true,
+		"application/octet-stream":          false,
+		"image/png":                         false,
+		"multipart/form-data; boundary=x":   false,
+		"text":                              false,
+		"":                                  false,
+	} {
+		if IsTextual(contentType) != expected {
+			t.Errorf("Expected %v for %q.", expected, contentType)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+// BodyLimit is a middleware limiting the size of the request bodies. A request whose Content-Length exceeds the
+// limit is handled by ErrorHandler, which responds with a StatusRequestEntityTooLarge by default. Otherwise the
+// body is wrapped with http.MaxBytesReader: reading beyond the limit fails with a *http.MaxBytesError, that the
+// handlers can detect with errors.As.
+//
+// Put it before ContentTypeChecker in the chain, so that the transcoded bodies are limited too.
+type BodyLimit struct {
+	// Limit is the maximum size of the bodies, in bytes. A negative limit means no limit.
+	Limit int64
+	// ContentLimits override Limit for some media types. The first one matching the request is used.
+	ContentLimits []ContentLimit
+	ErrorHandler  http.Handler
+}
+
+// ContentLimit is the limit of the bodies whose media type matches Pattern, like "multipart/*" or
+// "application/*+json", see ContentTypeChecker.AcceptedContents.
+type ContentLimit struct {
+	Pattern string
+	Limit   int64
+}
+
+// NewBodyLimit creates a BodyLimit with a global limit, in bytes.
+func NewBodyLimit(limit int64) *BodyLimit {
+	m := &BodyLimit{Limit: limit}
+	m.ErrorHandler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		limit := m.limit(request)
+		problem := render.NewProblem(http.StatusRequestEntityTooLarge).
+			SetDetail("the body exceeds "+strconv.FormatInt(limit, 10)+" bytes").
+			SetExtension("limit", limit)
+		rejectionProblems.RenderRequest(writer, request, problem)
+	})
+	return m
+}
+
+func (m *BodyLimit) SetLimit(limit int64) *BodyLimit {
+	m.Limit = limit
+	return m
+}
+
+// AddContentLimit defines the limit of the bodies of the media types matching the pattern.
+func (m *BodyLimit) AddContentLimit(pattern string, limit int64) *BodyLimit {
+	m.ContentLimits = append(m.ContentLimits, ContentLimit{Pattern: pattern, Limit: limit})
+	return m
+}
+
+func (m *BodyLimit) SetErrorHandler(handler http.Handler) *BodyLimit {
+	m.ErrorHandler = handler
+	return m
+}
+
+// limit returns the limit of the body of the request.
+func (m *BodyLimit) limit(request *http.Request) int64 {
+	if len(m.ContentLimits) > 0 {
+		mediatype, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		if err == nil {
+			for _, content := range m.ContentLimits {
+				if matchMediaType(content.Pattern, mediatype, params) {
+					return content.Limit
+				}
+			}
+		}
+	}
+	return m.Limit
+}
+
+// Check is the Middleware function to use in the chain.
+func (m *BodyLimit) Check(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		limit := m.limit(request)
+		if limit < 0 || request.Body == nil || request.Body == http.NoBody {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		if request.ContentLength > limit {
+			m.ErrorHandler.ServeHTTP(writer, request)
+			return
+		}
+		request.Body = http.MaxBytesReader(writer, request.Body, limit)
+		next.ServeHTTP(writer, request)
+	})
+}
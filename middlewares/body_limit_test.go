@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readingHandler reads the whole body and records the error.
+func readingHandler(err *error) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, *err = io.ReadAll(request.Body)
+	})
+}
+
+func postWithLength(handler http.Handler, contentType string, body io.Reader, length int64) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/", body)
+	request.Header.Set("Content-Type", contentType)
+	request.ContentLength = length
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_BodyLimit_WithContentLengthAboveTheLimit_ShouldRespondRequestEntityTooLarge(t *testing.T) {
+	called := false
+	handler := Chain(NewBodyLimit(10).Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		called = true
+	}))
+	recorder := postWithLength(handler, "text/plain", strings.NewReader("01234567890"), 11)
+	expect(t, recorder.Code, http.StatusRequestEntityTooLarge)
+	expect(t, recorder.Body.String(), `{"title":"Request Entity Too Large","status":413,"detail":"the body exceeds 10 bytes","limit":10}`)
+	expect(t, called, false)
+}
+
+func Test_BodyLimit_WithinTheLimit_ShouldReadTheBody(t *testing.T) {
+	var err error
+	recorder := postWithLength(Chain(NewBodyLimit(10).Check).Then(readingHandler(&err)), "text/plain", strings.NewReader("0123456789"), 10)
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, err, nil)
+}
+
+func Test_BodyLimit_WithUnknownLength_ShouldFailWhenReadingBeyondTheLimit(t *testing.T) {
+	var err error
+	postWithLength(Chain(NewBodyLimit(10).Check).Then(readingHandler(&err)), "text/plain", strings.NewReader("01234567890"), -1)
+	var tooLarge *http.MaxBytesError
+	expect(t, errors.As(err, &tooLarge), true)
+	expect(t, tooLarge.Limit, int64(10))
+}
+
+func Test_BodyLimit_WithContentLimits_ShouldUseTheFirstMatchingOne(t *testing.T) {
+	var err error
+	limit := NewBodyLimit(4).AddContentLimit("multipart/*", 20).AddContentLimit("image/png", -1).AddContentLimit("*/*", 2)
+	handler := Chain(limit.Check).Then(readingHandler(&err))
+	body := "0123456789"
+
+	expect(t, postWithLength(handler, "multipart/form-data; boundary=x", strings.NewReader(body), 10).Code, http.StatusOK)
+	expect(t, postWithLength(handler, "image/png", strings.NewReader(body), 10).Code, http.StatusOK)
+	expect(t, postWithLength(handler, "text/plain", strings.NewReader("012"), 3).Code, http.StatusRequestEntityTooLarge)
+	expect(t, postWithLength(handler, "", strings.NewReader("012"), 3).Code, http.StatusOK)
+	expect(t, postWithLength(handler, "", strings.NewReader(body), 10).Code, http.StatusRequestEntityTooLarge)
+}
+
+func Test_BodyLimit_WithContentTypeChecker_ShouldLimitTheTranscodedBodies(t *testing.T) {
+	var err error
+	checker := NewContentTypeChecker().SetAcceptedCharset("UTF-8", "ISO-8859-1").SetTranscode(true)
+	handler := Chain(NewBodyLimit(4).Check, checker.Check).Then(readingHandler(&err))
+	recorder := postWithLength(handler, "text/plain; charset=ISO-8859-1", strings.NewReader("caf\xe9s"), -1)
+	expect(t, recorder.Code, http.StatusRequestEntityTooLarge)
+	expect(t, strings.Contains(recorder.Body.String(), `"detail":"the body exceeds 4 bytes"`), true)
+}
+
+func Test_BodyLimit_WithContentTypeChecker_ShouldNotKeepTheStatusOfTheTooLargeBodies(t *testing.T) {
+	var err error
+	checker := NewContentTypeChecker().SetAcceptedCharset("UTF-8", "Shift_JIS").SetTranscode(true)
+	handler := Chain(NewBodyLimit(4).Check, checker.Check).Then(readingHandler(&err))
+	recorder := postWithLength(handler, "text/plain; charset=Shift_JIS", strings.NewReader("abcde"), -1)
+	expect(t, recorder.Code, http.StatusRequestEntityTooLarge)
+	recorder = postWithLength(handler, "text/plain; charset=Shift_JIS", strings.NewReader("\x82"), -1)
+	expect(t, recorder.Code, http.StatusBadRequest)
+	expect(t, strings.Contains(recorder.Body.String(), `"detail":"the body is not valid Shift_JIS"`), true)
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Predicate tells whether a request is concerned by a middleware.
+type Predicate func(request *http.Request) bool
+
+// PathPrefix returns a predicate matching the requests whose path is prefix or is below it: "/api" matches
+// "/api" and "/api/users", but not "/apis".
+func PathPrefix(prefix string) Predicate {
+	root := strings.TrimSuffix(prefix, "/")
+	return func(request *http.Request) bool {
+		path := request.URL.Path
+		return path == root || strings.HasPrefix(path, root+"/")
+	}
+}
+
+// Methods returns a predicate matching the requests having one of the methods.
+func Methods(methods ...string) Predicate {
+	methods = upperCase(methods)
+	return func(request *http.Request) bool {
+		return contains(methods, request.Method)
+	}
+}
+
+// When returns a middleware applying the middlewares, in order, to the requests matching the predicate only.
+// The other requests go directly to the next handler:
+//
+//	Chain(Recovery, Unless(PathPrefix("/metrics"), Compress()), When(Methods("POST"), checker.Check))
+func When(predicate Predicate, middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := Chain(middlewares...).Then(next)
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if predicate(request) {
+				wrapped.ServeHTTP(writer, request)
+			} else {
+				next.ServeHTTP(writer, request)
+			}
+		})
+	}
+}
+
+// Unless returns a middleware applying the middlewares to the requests not matching the predicate only.
+func Unless(predicate Predicate, middlewares ...Middleware) Middleware {
+	return When(func(request *http.Request) bool { return !predicate(request) }, middlewares...)
+}
+
+// ForPathPrefix returns a middleware applying the middlewares to the requests below the prefix only, see PathPrefix.
+func ForPathPrefix(prefix string, middlewares ...Middleware) Middleware {
+	return When(PathPrefix(prefix), middlewares...)
+}
+
+// ForMethods returns a middleware applying the middlewares to the requests having one of the methods only.
+func ForMethods(methods []string, middlewares ...Middleware) Middleware {
+	return When(Methods(methods...), middlewares...)
+}
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tagging returns a middleware adding its tag to the X-Tags header.
+func tagging(tag string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Add("X-Tags", tag)
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+func tags(handler http.Handler, method string, path string) []string {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder.Header().Values("X-Tags")
+}
+
+func expectTags(t *testing.T, handler http.Handler, method string, path string, expected ...string) {
+	actual := tags(handler, method, path)
+	if len(actual) != len(expected) {
+		t.Errorf("Expected tags %v for %s %s, got %v.", expected, method, path, actual)
+		return
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected tags %v for %s %s, got %v.", expected, method, path, actual)
+			return
+		}
+	}
+}
+
+func Test_When_ShouldApplyTheMiddlewaresInOrderToTheMatchingRequests(t *testing.T) {
+	admin := func(request *http.Request) bool { return request.URL.Query().Get("admin") != "" }
+	handler := Chain(tagging("a"), When(admin, tagging("b"), tagging("c")), tagging("d")).Then(nil)
+	expectTags(t, handler, "GET", "/?admin=1", "a", "b", "c", "d")
+	expectTags(t, handler, "GET", "/", "a", "d")
+}
+
+func Test_Unless_ShouldApplyTheMiddlewaresToTheOtherRequests(t *testing.T) {
+	handler := Chain(Unless(PathPrefix("/metrics"), tagging("compress"))).Then(nil)
+	expectTags(t, handler, "GET", "/metrics")
+	expectTags(t, handler, "GET", "/metrics/cpu")
+	expectTags(t, handler, "GET", "/users", "compress")
+}
+
+func Test_ForPathPrefix_ShouldMatchWholeSegments(t *testing.T) {
+	for _, prefix := range []string{"/api", "/api/"} {
+		handler := Chain(ForPathPrefix(prefix, tagging("api"))).Then(nil)
+		expectTags(t, handler, "GET", "/api", "api")
+		expectTags(t, handler, "GET", "/api/", "api")
+		expectTags(t, handler, "GET", "/api/users/1", "api")
+		expectTags(t, handler, "GET", "/apis")
+		expectTags(t, handler, "GET", "/")
+	}
+	expectTags(t, Chain(ForPathPrefix("/", tagging("root"))).Then(nil), "GET", "/anything", "root")
+}
+
+func Test_ForMethods_ShouldMatchTheMethods(t *testing.T) {
+	handler := Chain(ForMethods([]string{"post", "PUT"}, tagging("write"))).Then(nil)
+	expectTags(t, handler, "POST", "/", "write")
+	expectTags(t, handler, "PUT", "/", "write")
+	expectTags(t, handler, "GET", "/")
+}
+
+func Test_ForPathPrefix_WithContentTypeChecker_ShouldOnlyCheckTheAPI(t *testing.T) {
+	checker := NewContentTypeChecker().SetAcceptedContents("application/json")
+	handler := Chain(ForPathPrefix("/api", checker.Check)).Then(nil)
+	expect(t, processRequestWithBody(t, "POST", "/api/users", handler, "text/html", "<html>").Code, http.StatusUnsupportedMediaType)
+	expect(t, processRequestWithBody(t, "POST", "/form", handler, "text/html", "<html>").Code, http.StatusOK)
+}
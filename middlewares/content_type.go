@@ -3,23 +3,80 @@ package middlewares
 import (
 	"mime"
 	"net/http"
+	"path"
 	"strings"
+
+	"github.com/deliverous/cocktails/internal/media"
 )
 
+// ContentTypeChecker is a middleware rejecting the requests whose body has a content type or a charset which is
+// not accepted. Only the requests having a body and a checked method are checked. The rejected requests, including
+// the ones with a malformed Content-Type header, are handled by ErrorHandler, which responds with a
+// StatusUnsupportedMediaType by default. The default handlers respond with a problem details body, and advertise
+// the accepted media types in the Accept-Post, Accept-Patch or Accept header. The reason of the rejection is
+// available to the handlers with ContentTypeRejectionOf.
 type ContentTypeChecker struct {
+	// AcceptedContents are the accepted media types. They can contain wildcards, like "text/*" or "*/*", RFC 6839
+	// structured suffixes, like "application/*+json", and parameters which must be present in the request with
+	// the same value, like "application/vnd.api+json; version=2". Empty means anything is accepted.
 	AcceptedContents []string
 	AcceptedCharsets []string
 	AssumedCharset   string
 	ErrorHandler     http.Handler
+	// Transcode defines whether the accepted bodies of textual media types, or having a charset parameter, are
+	// converted to UTF-8 from their charset, or AssumedCharset. The Content-Type charset parameter is rewritten
+	// to UTF-8. The charsets are then compared by their IANA name, so that an alias like latin1 is accepted as
+	// ISO-8859-1. Default is false.
+	Transcode bool
+	// MaxTranscodedSize is the maximum size of the bodies to transcode, in bytes. These bodies are read in memory,
+	// to reject the invalid ones before calling the next handler, so a larger body is rejected as if it exceeded
+	// the limit of BodyLimit. A negative size means no limit. Default is 10 MB.
+	MaxTranscodedSize int64
+	// InvalidBodyHandler handles the requests whose body cannot be transcoded, because of an invalid byte sequence
+	// for instance. Default responds with a StatusBadRequest, or a StatusRequestEntityTooLarge when the body
+	// exceeds the limit of BodyLimit or MaxTranscodedSize.
+	InvalidBodyHandler http.Handler
+	// CheckedMethods are the methods whose requests are checked. Default is PUT, POST and PATCH.
+	CheckedMethods []string
+	// Routes define the accepted media types of some requests, instead of AcceptedContents. The first matching
+	// route is used.
+	Routes []ContentTypeRoute
+}
+
+// ContentTypeRoute defines the media types accepted for the requests matching a method and a path.
+type ContentTypeRoute struct {
+	// Method is the method of the requests, empty for any method.
+	Method string
+	// Path is a pattern of the request path, as defined by path.Match, or the root of a subtree when ending with a
+	// slash, like "/api/". Empty means any path.
+	Path string
+	// AcceptedContents are the accepted media types, as for ContentTypeChecker.AcceptedContents.
+	AcceptedContents []string
+}
+
+func (route ContentTypeRoute) match(request *http.Request) bool {
+	if route.Method != "" && !strings.EqualFold(route.Method, request.Method) {
+		return false
+	}
+	switch {
+	case route.Path == "":
+		return true
+	case strings.HasSuffix(route.Path, "/"):
+		return strings.HasPrefix(request.URL.Path, route.Path)
+	default:
+		matched, _ := path.Match(route.Path, request.URL.Path)
+		return matched
+	}
 }
 
 func NewContentTypeChecker() *ContentTypeChecker {
 	return &ContentTypeChecker{
-		AssumedCharset:   "UTF-8",
-		AcceptedCharsets: []string{"UTF-8"},
-		ErrorHandler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-			writer.WriteHeader(http.StatusUnsupportedMediaType)
-		}),
+		AssumedCharset:     "UTF-8",
+		AcceptedCharsets:   []string{"UTF-8"},
+		CheckedMethods:     []string{"PUT", "POST", "PATCH"},
+		MaxTranscodedSize:  10 << 20,
+		InvalidBodyHandler: rejectionHandler(http.StatusBadRequest),
+		ErrorHandler:       rejectionHandler(http.StatusUnsupportedMediaType),
 	}
 }
 
@@ -46,25 +103,80 @@ func (m *ContentTypeChecker) SetErrorHandler(handler http.Handler) *ContentTypeC
 	return m
 }
 
-func (m *ContentTypeChecker) acceptContent(value string) bool {
-	if len(m.AcceptedContents) == 0 {
+// SetTranscode defines whether the bodies are converted to UTF-8. The charsets to convert from must be accepted:
+//
+//	NewContentTypeChecker().SetAcceptedCharset("UTF-8", "ISO-8859-1", "windows-1252").SetTranscode(true)
+func (m *ContentTypeChecker) SetTranscode(value bool) *ContentTypeChecker {
+	m.Transcode = value
+	return m
+}
+
+func (m *ContentTypeChecker) SetMaxTranscodedSize(size int64) *ContentTypeChecker {
+	m.MaxTranscodedSize = size
+	return m
+}
+
+func (m *ContentTypeChecker) SetInvalidBodyHandler(handler http.Handler) *ContentTypeChecker {
+	m.InvalidBodyHandler = handler
+	return m
+}
+
+// SetCheckedMethods defines the methods whose requests are checked, like DELETE or QUERY when they have a body.
+func (m *ContentTypeChecker) SetCheckedMethods(methods ...string) *ContentTypeChecker {
+	m.CheckedMethods = upperCase(methods)
+	return m
+}
+
+// AddRoute defines the media types accepted for the requests matching the method and the path, see ContentTypeRoute.
+func (m *ContentTypeChecker) AddRoute(method string, path string, contents ...string) *ContentTypeChecker {
+	m.Routes = append(m.Routes, ContentTypeRoute{Method: method, Path: path, AcceptedContents: contents})
+	return m
+}
+
+// checked reports whether the request has a checked method and a body, which may have an unknown length.
+func (m *ContentTypeChecker) checked(request *http.Request) bool {
+	if request.Body == nil || request.Body == http.NoBody || request.ContentLength == 0 {
+		return false
+	}
+	return contains(m.CheckedMethods, request.Method)
+}
+
+// acceptedContents returns the media types accepted for the request.
+func (m *ContentTypeChecker) acceptedContents(request *http.Request) []string {
+	for _, route := range m.Routes {
+		if route.match(request) {
+			return route.AcceptedContents
+		}
+	}
+	return m.AcceptedContents
+}
+
+func acceptContent(accepted []string, value string, params map[string]string) bool {
+	if len(accepted) == 0 {
 		return true
 	}
-	for _, content := range m.AcceptedContents {
-		if value == content {
+	for _, content := range accepted {
+		if matchMediaType(content, value, params) {
 			return true
 		}
 	}
 	return false
 }
 
+// acceptCharset reports whether the charset is accepted. When transcoding, the aliases of the charsets, like
+// latin1 for ISO-8859-1, are accepted too.
 func (m *ContentTypeChecker) acceptCharset(value string) bool {
 	if len(m.AcceptedCharsets) == 0 {
 		return true
 	}
-	value = strings.ToUpper(value)
+	if m.Transcode {
+		value = canonicalCharset(value)
+	}
 	for _, charset := range m.AcceptedCharsets {
-		if value == charset {
+		if m.Transcode {
+			charset = canonicalCharset(charset)
+		}
+		if strings.EqualFold(value, charset) {
 			return true
 		}
 	}
@@ -73,20 +185,60 @@ func (m *ContentTypeChecker) acceptCharset(value string) bool {
 
 func (m *ContentTypeChecker) Check(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		if !(request.Method == "PUT" || request.Method == "POST" || request.Method == "PATCH") {
+		if !m.checked(request) {
 			next.ServeHTTP(writer, request)
 			return
 		}
 
-		mediatype, params, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		header := request.Header.Get("Content-Type")
+		accepted := m.acceptedContents(request)
+		reject := func(handler http.Handler, reason RejectionReason, charset string, err error) {
+			handler.ServeHTTP(writer, withRejection(request, &ContentTypeRejection{
+				Reason:           reason,
+				ContentType:      header,
+				Charset:          charset,
+				AcceptedContents: accepted,
+				AcceptedCharsets: m.AcceptedCharsets,
+				Err:              err,
+			}))
+		}
+
+		var mediatype string
+		var params map[string]string
+		if header != "" {
+			var err error
+			if mediatype, params, err = mime.ParseMediaType(header); err != nil {
+				reject(m.ErrorHandler, MalformedContentType, "", nil)
+				return
+			}
+		}
 		charset, ok := params["charset"]
 		if !ok {
 			charset = m.AssumedCharset
 		}
-		if m.acceptContent(mediatype) && m.acceptCharset(charset) {
-			next.ServeHTTP(writer, request)
-		} else {
-			m.ErrorHandler.ServeHTTP(writer, request)
+		if !acceptContent(accepted, mediatype, params) {
+			reject(m.ErrorHandler, RejectedMediaType, charset, nil)
+			return
+		}
+		if !m.acceptCharset(charset) {
+			reject(m.ErrorHandler, RejectedCharset, charset, nil)
+			return
+		}
+		if m.Transcode && (ok || media.IsTextual(mediatype)) {
+			encoding, err := charsetEncoding(charset)
+			if err != nil {
+				reject(m.ErrorHandler, RejectedCharset, charset, nil)
+				return
+			}
+			if encoding != nil {
+				transcoded, err := transcodeRequest(request, mediatype, params, encoding, m.MaxTranscodedSize)
+				if err != nil {
+					reject(m.InvalidBodyHandler, InvalidBody, charset, err)
+					return
+				}
+				request = transcoded
+			}
 		}
+		next.ServeHTTP(writer, request)
 	})
 }
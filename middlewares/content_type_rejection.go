@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+// RejectionReason tells why ContentTypeChecker rejected a request.
+type RejectionReason string
+
+const (
+	// RejectedMediaType means the media type of the body is not accepted.
+	RejectedMediaType RejectionReason = "media-type"
+	// RejectedCharset means the charset of the body is not accepted, or cannot be transcoded.
+	RejectedCharset RejectionReason = "charset"
+	// MalformedContentType means the Content-Type header cannot be parsed.
+	MalformedContentType RejectionReason = "malformed-content-type"
+	// InvalidBody means the body cannot be read or transcoded to UTF-8.
+	InvalidBody RejectionReason = "invalid-body"
+)
+
+// ContentTypeRejection describes a request rejected by ContentTypeChecker. It is available to the error handlers
+// with ContentTypeRejectionOf.
+type ContentTypeRejection struct {
+	Reason RejectionReason
+	// ContentType is the Content-Type header of the request.
+	ContentType string
+	// Charset is the charset of the body, possibly the assumed one.
+	Charset          string
+	AcceptedContents []string
+	AcceptedCharsets []string
+	// Err is the error reading or transcoding the body, for InvalidBody.
+	Err error
+}
+
+type rejectionKey struct{}
+
+func withRejection(request *http.Request, rejection *ContentTypeRejection) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), rejectionKey{}, rejection))
+}
+
+// ContentTypeRejectionOf returns the rejection of the request by ContentTypeChecker, nil if not rejected.
+func ContentTypeRejectionOf(request *http.Request) *ContentTypeRejection {
+	rejection, _ := request.Context().Value(rejectionKey{}).(*ContentTypeRejection)
+	return rejection
+}
+
+var rejectionProblems = render.NewProblemRender()
+
+// rejectionHandler responds with a problem describing the rejection with the status. The accepted media types
+// are advertised by the Accept-Post, Accept-Patch or Accept header, according to the method of the request.
+func rejectionHandler(status int) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		rejection := ContentTypeRejectionOf(request)
+		code := status
+		var tooLarge *http.MaxBytesError
+		if rejection != nil && errors.As(rejection.Err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		problem := render.NewProblem(code)
+		if rejection == nil {
+			rejectionProblems.RenderRequest(writer, request, problem)
+			return
+		}
+
+		if len(rejection.AcceptedContents) > 0 {
+			header := "Accept"
+			switch request.Method {
+			case "POST":
+				header = "Accept-Post"
+			case "PATCH":
+				header = "Accept-Patch"
+			}
+			writer.Header().Set(header, strings.Join(rejection.AcceptedContents, ", "))
+			problem.SetExtension("acceptedContents", rejection.AcceptedContents)
+		}
+		if len(rejection.AcceptedCharsets) > 0 {
+			problem.SetExtension("acceptedCharsets", rejection.AcceptedCharsets)
+		}
+		problem.SetExtension("reason", rejection.Reason)
+		switch rejection.Reason {
+		case RejectedMediaType:
+			problem.SetDetail("the media type " + quoteOrNone(rejection.ContentType) + " is not accepted")
+		case RejectedCharset:
+			problem.SetDetail("the charset " + quoteOrNone(rejection.Charset) + " is not accepted")
+		case MalformedContentType:
+			problem.SetDetail("the Content-Type header " + quoteOrNone(rejection.ContentType) + " is malformed")
+		case InvalidBody:
+			if tooLarge != nil {
+				problem.SetDetail("the body exceeds " + strconv.FormatInt(tooLarge.Limit, 10) + " bytes")
+			} else {
+				problem.SetDetail("the body is not valid " + rejection.Charset)
+			}
+		}
+		rejectionProblems.RenderRequest(writer, request, problem)
+	})
+}
+
+func quoteOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return `"` + value + `"`
+}
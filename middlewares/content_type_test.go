@@ -3,7 +3,10 @@ package middlewares
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/deliverous/cocktails/render"
 )
 
 func Test_ContentType_WithNoSpecifiedContentType_ShouldAcceptAnything(t *testing.T) {
@@ -90,6 +93,16 @@ func Test_ContentType_SettingCustomErrorHandler_ShouldCallTheHandler(t *testing.
 	expect(t, called, true)
 }
 
+func Test_ContentType_WithProblemErrorHandler_ShouldRenderAProblem(t *testing.T) {
+	problems := render.NewProblemRender()
+	checker := NewContentTypeChecker().SetAcceptedContents("application/json").
+		SetErrorHandler(problems.Handler(render.NewProblem(http.StatusUnsupportedMediaType)))
+	recorder := processRequestWithContent(t, "POST", Chain(checker.Check).Then(nil), "text/html")
+	expect(t, recorder.Code, http.StatusUnsupportedMediaType)
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"title":"Unsupported Media Type","status":415}`)
+}
+
 func acceptContentType(t *testing.T, method string, handler http.Handler, contentType string) {
 	if processRequestWithContent(t, method, handler, contentType).Code != http.StatusOK {
 		t.Errorf("ContentType failure: sould have accepted %s", contentType)
@@ -103,12 +116,157 @@ func rejectContentType(t *testing.T, method string, handler http.Handler, conten
 }
 
 func processRequestWithContent(t *testing.T, method string, handler http.Handler, contentType string) *httptest.ResponseRecorder {
+	return processRequestWithBody(t, method, "/", handler, contentType, "content")
+}
+
+func processRequestWithBody(t *testing.T, method string, url string, handler http.Handler, contentType string, body string) *httptest.ResponseRecorder {
 	recorder := httptest.NewRecorder()
-	request, err := http.NewRequest(method, "/", nil)
-	if err != nil {
-		t.Fatal(err)
-	}
+	request := httptest.NewRequest(method, url, strings.NewReader(body))
 	request.Header.Set("Content-Type", contentType)
 	handler.ServeHTTP(recorder, request)
 	return recorder
 }
+
+func Test_ContentType_WithWildcards_ShouldAcceptTheMatchingContentTypes(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("text/*", "application/*+json").Check).Then(nil)
+	acceptContentType(t, "POST", handler, "text/plain")
+	acceptContentType(t, "POST", handler, "Text/CSV")
+	acceptContentType(t, "POST", handler, "application/problem+json")
+	acceptContentType(t, "POST", handler, "application/vnd.api+json; version=2")
+	rejectContentType(t, "POST", handler, "application/json")
+	rejectContentType(t, "POST", handler, "application/+json")
+	rejectContentType(t, "POST", handler, "application/problem+xml")
+	rejectContentType(t, "POST", handler, "image/png")
+}
+
+func Test_ContentType_WithAnyType_ShouldAcceptTheStructuredSuffixOfAnyType(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("*/*+xml").Check).Then(nil)
+	acceptContentType(t, "POST", handler, "image/svg+xml")
+	acceptContentType(t, "POST", handler, "application/atom+xml")
+	rejectContentType(t, "POST", handler, "text/xml")
+}
+
+func Test_ContentType_WithParameters_ShouldRequireTheirValues(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("application/vnd.example+json; version=2").Check).Then(nil)
+	acceptContentType(t, "POST", handler, "application/vnd.example+json; version=2")
+	acceptContentType(t, "POST", handler, "application/vnd.example+json; Version=\"2\"; profile=full")
+	rejectContentType(t, "POST", handler, "application/vnd.example+json; version=1")
+	rejectContentType(t, "POST", handler, "application/vnd.example+json")
+}
+
+func Test_ContentType_WithMalformedContentType_ShouldReject(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().Check).Then(nil)
+	rejectContentType(t, "POST", handler, "application/json; charset")
+	rejectContentType(t, "POST", handler, "application/json;;")
+	rejectContentType(t, "POST", handler, "application/")
+	rejectContentType(t, "POST", handler, "/json")
+	acceptContentType(t, "GET", handler, "/json")
+}
+
+func Test_ContentType_WithoutContentType_ShouldOnlyBeAcceptedWhenAnythingIs(t *testing.T) {
+	acceptContentType(t, "POST", Chain(NewContentTypeChecker().Check).Then(nil), "")
+	rejectContentType(t, "POST", Chain(NewContentTypeChecker().SetAcceptedContents("*/*").Check).Then(nil), "")
+}
+
+func Test_ContentType_WithCheckedMethods_ShouldOnlyCheckThem(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("application/json").SetCheckedMethods("post", "DELETE", "QUERY").Check).Then(nil)
+	rejectContentType(t, "POST", handler, "text/html")
+	rejectContentType(t, "DELETE", handler, "text/html")
+	rejectContentType(t, "QUERY", handler, "text/html")
+	acceptContentType(t, "PUT", handler, "text/html")
+	acceptContentType(t, "QUERY", handler, "application/json")
+}
+
+func Test_ContentType_WithoutBody_ShouldNotCheck(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("application/json").Check).Then(nil)
+	expect(t, processRequestWithBody(t, "POST", "/", handler, "text/html", "").Code, http.StatusOK)
+	expect(t, processRequestWithBody(t, "POST", "/", handler, "", "").Code, http.StatusOK)
+	expect(t, processRequestWithBody(t, "POST", "/", handler, "text/html", "<html>").Code, http.StatusUnsupportedMediaType)
+}
+
+func Test_ContentType_WithRoutes_ShouldUseTheFirstMatchingRoute(t *testing.T) {
+	checker := NewContentTypeChecker().SetAcceptedContents("application/json").
+		AddRoute("PUT", "/files/*", "application/octet-stream").
+		AddRoute("", "/upload/", "multipart/form-data").
+		AddRoute("PATCH", "", "application/merge-patch+json")
+	handler := Chain(checker.Check).Then(nil)
+
+	for _, test := range []struct {
+		method      string
+		url         string
+		contentType string
+		status      int
+	}{
+		{"PUT", "/files/report.pdf", "application/octet-stream", http.StatusOK},
+		{"PUT", "/files/report.pdf", "application/json", http.StatusUnsupportedMediaType},
+		{"PUT", "/files/2020/report.pdf", "application/json", http.StatusOK},
+		{"POST", "/files/report.pdf", "application/json", http.StatusOK},
+		{"POST", "/upload/images/1", "multipart/form-data; boundary=x", http.StatusOK},
+		{"PATCH", "/upload/images/1", "application/merge-patch+json", http.StatusUnsupportedMediaType},
+		{"PATCH", "/users/1", "application/merge-patch+json", http.StatusOK},
+		{"PATCH", "/users/1", "application/json", http.StatusUnsupportedMediaType},
+		{"POST", "/users", "multipart/form-data; boundary=x", http.StatusUnsupportedMediaType},
+	} {
+		recorder := processRequestWithBody(t, test.method, test.url, handler, test.contentType, "content")
+		if recorder.Code != test.status {
+			t.Errorf("Expected %d for %s %s with %s, got %d.", test.status, test.method, test.url, test.contentType, recorder.Code)
+		}
+	}
+}
+
+func Test_ContentType_DefaultErrorHandler_ShouldDescribeTheRejection(t *testing.T) {
+	handler := Chain(NewContentTypeChecker().SetAcceptedContents("application/json", "application/*+json").Check).Then(nil)
+	recorder := processRequestWithContent(t, "POST", handler, "text/html")
+	expect(t, recorder.Code, http.StatusUnsupportedMediaType)
+	expect(t, recorder.Header().Get("Accept-Post"), "application/json, application/*+json")
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"title":"Unsupported Media Type","status":415,`+
+		`"detail":"the media type \"text/html\" is not accepted",`+
+		`"acceptedCharsets":["UTF-8"],"acceptedContents":["application/json","application/*+json"],"reason":"media-type"}`)
+
+	recorder = processRequestWithContent(t, "PATCH", handler, "application/json; charset=UTF-16")
+	expect(t, recorder.Header().Get("Accept-Patch"), "application/json, application/*+json")
+	expect(t, strings.Contains(recorder.Body.String(), `"detail":"the charset \"UTF-16\" is not accepted"`), true)
+
+	recorder = processRequestWithContent(t, "PUT", handler, "application/json;;")
+	expect(t, recorder.Header().Get("Accept"), "application/json, application/*+json")
+	expect(t, strings.Contains(recorder.Body.String(), `"reason":"malformed-content-type"`), true)
+}
+
+func Test_ContentType_DefaultErrorHandler_WithoutAcceptedContents_ShouldNotAdvertiseThem(t *testing.T) {
+	recorder := processRequestWithContent(t, "POST", Chain(NewContentTypeChecker().Check).Then(nil), "text/plain; charset=latin1")
+	expect(t, recorder.Header().Get("Accept-Post"), "")
+	expect(t, strings.Contains(recorder.Body.String(), "acceptedContents"), false)
+	expect(t, strings.Contains(recorder.Body.String(), `"reason":"charset"`), true)
+}
+
+func Test_ContentType_CustomErrorHandler_ShouldReceiveTheRejection(t *testing.T) {
+	var rejections []*ContentTypeRejection
+	errorHandler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		rejections = append(rejections, ContentTypeRejectionOf(request))
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+	})
+	checker := NewContentTypeChecker().SetAcceptedContents("application/json").SetErrorHandler(errorHandler)
+	handler := Chain(checker.Check).Then(nil)
+	rejectContentType(t, "POST", handler, "text/html")
+	rejectContentType(t, "POST", handler, "application/json; charset=UTF-16")
+	rejectContentType(t, "POST", handler, "application/json; charset")
+
+	expect(t, len(rejections), 3)
+	expect(t, rejections[0].Reason, RejectedMediaType)
+	expect(t, rejections[0].ContentType, "text/html")
+	expect(t, rejections[0].Charset, "UTF-8")
+	expect(t, rejections[1].Reason, RejectedCharset)
+	expect(t, rejections[1].Charset, "UTF-16")
+	expect(t, rejections[2].Reason, MalformedContentType)
+	expect(t, rejections[2].AcceptedContents[0], "application/json")
+}
+
+func Test_ContentType_WhenAccepted_ShouldNotHaveARejection(t *testing.T) {
+	var rejection *ContentTypeRejection
+	handler := Chain(NewContentTypeChecker().Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		rejection = ContentTypeRejectionOf(request)
+	}))
+	processRequestWithContent(t, "POST", handler, "application/json")
+	expect(t, rejection == nil, true)
+}
@@ -0,0 +1,266 @@
+package middlewares
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is a middleware implementing the Cross-Origin Resource Sharing protocol of the Fetch standard.
+//
+// The CORS headers are added to the responses to the requests from an allowed origin. The preflight requests,
+// OPTIONS requests with Origin and Access-Control-Request-Method headers, are answered with
+// OptionsSuccessStatus without calling the next handler, unless OptionsPassthrough is set. When the origin, the
+// method, the headers or the private network access are not allowed, the preflight response has no CORS header,
+// which makes the browser fail the request.
+type CORS struct {
+	// AllowedOrigins are the allowed origins: exact origins like "https://example.com", origins with a wildcard
+	// subdomain like "https://*.example.com", or "*" for any origin. Default is "*".
+	//
+	// When any origin is allowed, the responses have an "Access-Control-Allow-Origin: *" header and never allow
+	// the credentials, as the Fetch standard requires: list the origins to allow the credentials.
+	AllowedOrigins []string
+	// OriginPatterns are regular expressions matching allowed origins.
+	OriginPatterns []*regexp.Regexp
+	// AllowOriginFunc, if set, allows the origins for which it returns true.
+	AllowOriginFunc func(origin string, request *http.Request) bool
+	// AllowedMethods are the methods allowed in addition to GET, HEAD and POST. Default is PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in addition to the CORS-safelisted ones, "*" for any header.
+	// Default is Content-Type.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers that the scripts can read, in addition to the CORS-safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials defines whether the requests can include the credentials, like the cookies. It is ignored
+	// when any origin is allowed. Default is false.
+	AllowCredentials bool
+	// MaxAge is how long the result of a preflight can be cached, not sent if zero. Default is zero.
+	MaxAge time.Duration
+	// AllowPrivateNetwork defines whether the requests from public networks to the private network of the server
+	// are allowed, see Private Network Access. Default is false.
+	AllowPrivateNetwork bool
+	// OptionsPassthrough defines whether the preflight requests are passed to the next handler. Default is false.
+	OptionsPassthrough bool
+	// OptionsSuccessStatus is the status of the preflight responses. Default is StatusNoContent.
+	OptionsSuccessStatus int
+}
+
+// NewCORS creates a new CORS with default values.
+func NewCORS() *CORS {
+	return &CORS{
+		AllowedOrigins:       []string{"*"},
+		AllowedMethods:       []string{"PUT", "PATCH", "DELETE"},
+		AllowedHeaders:       []string{"Content-Type"},
+		OptionsSuccessStatus: http.StatusNoContent,
+	}
+}
+
+func (m *CORS) SetAllowedOrigins(origins ...string) *CORS {
+	m.AllowedOrigins = origins
+	return m
+}
+
+// AddOriginPatterns adds regular expressions matching allowed origins. They must be anchored, like
+// `^https://[a-z]+\.example\.com$`, to prevent matching an origin containing an allowed one.
+func (m *CORS) AddOriginPatterns(patterns ...*regexp.Regexp) *CORS {
+	m.OriginPatterns = append(m.OriginPatterns, patterns...)
+	return m
+}
+
+func (m *CORS) SetAllowOriginFunc(function func(origin string, request *http.Request) bool) *CORS {
+	m.AllowOriginFunc = function
+	return m
+}
+
+func (m *CORS) SetAllowedMethods(methods ...string) *CORS {
+	m.AllowedMethods = upperCase(methods)
+	return m
+}
+
+func (m *CORS) SetAllowedHeaders(headers ...string) *CORS {
+	m.AllowedHeaders = headers
+	return m
+}
+
+func (m *CORS) SetExposedHeaders(headers ...string) *CORS {
+	m.ExposedHeaders = headers
+	return m
+}
+
+func (m *CORS) SetAllowCredentials(value bool) *CORS {
+	m.AllowCredentials = value
+	return m
+}
+
+func (m *CORS) SetMaxAge(value time.Duration) *CORS {
+	m.MaxAge = value
+	return m
+}
+
+func (m *CORS) SetAllowPrivateNetwork(value bool) *CORS {
+	m.AllowPrivateNetwork = value
+	return m
+}
+
+func (m *CORS) SetOptionsPassthrough(value bool) *CORS {
+	m.OptionsPassthrough = value
+	return m
+}
+
+func (m *CORS) SetOptionsSuccessStatus(value int) *CORS {
+	m.OptionsSuccessStatus = value
+	return m
+}
+
+// anyOrigin reports whether any origin is allowed.
+func (m *CORS) anyOrigin() bool {
+	return contains(m.AllowedOrigins, "*")
+}
+
+func (m *CORS) allowOrigin(origin string, request *http.Request) bool {
+	if m.anyOrigin() {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	for _, allowed := range m.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == lower {
+			return true
+		}
+		if star := strings.Index(allowed, "*"); star >= 0 && matchWildcardOrigin(allowed[:star], allowed[star+1:], lower) {
+			return true
+		}
+	}
+	for _, pattern := range m.OriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return m.AllowOriginFunc != nil && m.AllowOriginFunc(origin, request)
+}
+
+// matchWildcardOrigin reports whether the origin is made of the prefix, a non empty host part, and the suffix.
+func matchWildcardOrigin(prefix string, suffix string, origin string) bool {
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	return !strings.ContainsAny(origin[len(prefix):len(origin)-len(suffix)], "/:@?#")
+}
+
+// allowMethod reports whether the method is allowed. The CORS-safelisted methods always are.
+func (m *CORS) allowMethod(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "POST" || contains(m.AllowedMethods, method)
+}
+
+// allowHeaders reports whether the headers of an Access-Control-Request-Headers header are allowed.
+func (m *CORS) allowHeaders(headers []string) bool {
+	if contains(m.AllowedHeaders, "*") {
+		return true
+	}
+	for _, header := range headers {
+		if !contains(m.AllowedHeaders, header) {
+			return false
+		}
+	}
+	return true
+}
+
+// Handle is the Middleware function to use in the chain.
+func (m *CORS) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == "OPTIONS" && request.Header.Get("Access-Control-Request-Method") != "" {
+			m.preflight(writer, request)
+			if m.OptionsPassthrough {
+				next.ServeHTTP(writer, request)
+			} else {
+				writer.WriteHeader(m.OptionsSuccessStatus)
+			}
+			return
+		}
+		m.actual(writer, request)
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// allowOriginHeaders adds the Access-Control-Allow-Origin and Access-Control-Allow-Credentials headers. The
+// credentials are never allowed with any origin, otherwise any site could read the responses to the requests
+// made with the cookies of the user.
+func (m *CORS) allowOriginHeaders(header http.Header, origin string) {
+	if m.anyOrigin() {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if m.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (m *CORS) preflight(writer http.ResponseWriter, request *http.Request) {
+	header := writer.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	if m.AllowPrivateNetwork {
+		header.Add("Vary", "Access-Control-Request-Private-Network")
+	}
+
+	origin := request.Header.Get("Origin")
+	if origin == "" || !m.allowOrigin(origin, request) {
+		return
+	}
+	method := request.Header.Get("Access-Control-Request-Method")
+	if !m.allowMethod(method) {
+		return
+	}
+	requested := requestedHeaders(request)
+	if !m.allowHeaders(requested) {
+		return
+	}
+	privateNetwork := request.Header.Get("Access-Control-Request-Private-Network") == "true"
+	if privateNetwork && !m.AllowPrivateNetwork {
+		return
+	}
+
+	m.allowOriginHeaders(header, origin)
+	header.Set("Access-Control-Allow-Methods", method)
+	if len(requested) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if m.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.FormatInt(int64(m.MaxAge/time.Second), 10))
+	}
+	if privateNetwork {
+		header.Set("Access-Control-Allow-Private-Network", "true")
+	}
+}
+
+func (m *CORS) actual(writer http.ResponseWriter, request *http.Request) {
+	header := writer.Header()
+	if !m.anyOrigin() {
+		header.Add("Vary", "Origin")
+	}
+	origin := request.Header.Get("Origin")
+	if origin == "" || !m.allowOrigin(origin, request) {
+		return
+	}
+	m.allowOriginHeaders(header, origin)
+	if len(m.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(m.ExposedHeaders, ", "))
+	}
+}
+
+// requestedHeaders returns the headers of the Access-Control-Request-Headers headers.
+func requestedHeaders(request *http.Request) []string {
+	var headers []string
+	for _, value := range request.Header.Values("Access-Control-Request-Headers") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				headers = append(headers, strings.ToLower(name))
+			}
+		}
+	}
+	return headers
+}
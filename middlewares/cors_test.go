@@ -0,0 +1,211 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type corsCase struct {
+	name    string
+	method  string
+	headers map[string]string
+	// status is the expected status, StatusOK when the next handler is called.
+	status int
+	// expected are the expected response headers, an empty value meaning absent.
+	expected map[string]string
+}
+
+// corsHeaders are the response headers checked by default.
+var corsHeaders = []string{
+	"Access-Control-Allow-Origin",
+	"Access-Control-Allow-Credentials",
+	"Access-Control-Allow-Methods",
+	"Access-Control-Allow-Headers",
+	"Access-Control-Expose-Headers",
+	"Access-Control-Max-Age",
+	"Access-Control-Allow-Private-Network",
+}
+
+func checkCORS(t *testing.T, cors *CORS, cases []corsCase) {
+	handler := Chain(cors.Handle).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	}))
+	for _, test := range cases {
+		request := httptest.NewRequest(test.method, "https://api.example.com/resource", nil)
+		for name, value := range test.headers {
+			request.Header.Set(name, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d.", test.name, test.status, recorder.Code)
+		}
+		for _, name := range corsHeaders {
+			if _, ok := test.expected[name]; !ok {
+				test.expected[name] = ""
+			}
+		}
+		for name, expected := range test.expected {
+			if value := strings.Join(recorder.Header().Values(name), ", "); value != expected {
+				t.Errorf("%s: expected %s %q, got %q.", test.name, name, expected, value)
+			}
+		}
+	}
+}
+
+func preflight(origin string, method string, headers ...string) map[string]string {
+	result := map[string]string{"Origin": origin, "Access-Control-Request-Method": method}
+	if len(headers) > 0 {
+		result["Access-Control-Request-Headers"] = strings.Join(headers, ",")
+	}
+	return result
+}
+
+const preflightVary = "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"
+
+func Test_CORS_WithDefaults_ShouldAllowAnyOriginWithoutCredentials(t *testing.T) {
+	checkCORS(t, NewCORS(), []corsCase{
+		{"same origin request without Origin", "GET", nil, http.StatusOK,
+			map[string]string{"Vary": ""}},
+		{"simple request", "GET", map[string]string{"Origin": "https://app.example.com"}, http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Vary": ""}},
+		{"null origin", "POST", map[string]string{"Origin": "null"}, http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "*"}},
+		{"preflight", "OPTIONS", preflight("https://app.example.com", "PUT", "Content-Type"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "PUT",
+				"Access-Control-Allow-Headers": "content-type", "Vary": preflightVary}},
+		{"preflight of a safelisted method", "OPTIONS", preflight("https://app.example.com", "POST"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "POST"}},
+		{"preflight of a method not allowed", "OPTIONS", preflight("https://app.example.com", "PURGE"), http.StatusNoContent,
+			map[string]string{"Vary": preflightVary}},
+		{"preflight of a lower case method", "OPTIONS", preflight("https://app.example.com", "patch"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "patch"}},
+		{"preflight of a header not allowed", "OPTIONS", preflight("https://app.example.com", "PUT", "Content-Type", "X-Token"), http.StatusNoContent,
+			map[string]string{}},
+		{"OPTIONS which is not a preflight", "OPTIONS", map[string]string{"Origin": "https://app.example.com"}, http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "*"}},
+		{"preflight requesting private network access", "OPTIONS", map[string]string{
+			"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Private-Network": "true"},
+			http.StatusNoContent, map[string]string{}},
+	})
+}
+
+func Test_CORS_WithOrigins_ShouldOnlyAllowThem(t *testing.T) {
+	cors := NewCORS().
+		SetAllowedOrigins("https://example.com", "https://*.example.org", "http://localhost:*").
+		AddOriginPatterns(regexp.MustCompile(`^https://app-[0-9]+\.example\.net$`)).
+		SetAllowOriginFunc(func(origin string, request *http.Request) bool { return origin == "https://partner.test" })
+	var cases []corsCase
+	for origin, allowed := range map[string]bool{
+		"https://example.com":           true,
+		"HTTPS://EXAMPLE.COM":           true,
+		"http://example.com":            false,
+		"https://example.com:8443":      false,
+		"https://evil-example.com":      false,
+		"https://a.example.org":         true,
+		"https://a.b.example.org":       true,
+		"https://example.org":           false,
+		"https://.example.org":          false,
+		"https://evil.com/.example.org": false,
+		"https://evil.com#.example.org": false,
+		"http://localhost:3000":         true,
+		"http://localhost":              false,
+		"https://app-12.example.net":    true,
+		"https://app-x.example.net":     false,
+		"https://partner.test":          true,
+		"null":                          false,
+	} {
+		expected := map[string]string{"Vary": "Origin"}
+		if allowed {
+			expected["Access-Control-Allow-Origin"] = origin
+		}
+		cases = append(cases, corsCase{"simple request from " + origin, "GET", map[string]string{"Origin": origin}, http.StatusOK, expected})
+
+		expected = map[string]string{"Vary": preflightVary}
+		if allowed {
+			expected["Access-Control-Allow-Origin"] = origin
+			expected["Access-Control-Allow-Methods"] = "DELETE"
+		}
+		cases = append(cases, corsCase{"preflight from " + origin, "OPTIONS", preflight(origin, "DELETE"), http.StatusNoContent, expected})
+	}
+	checkCORS(t, cors, cases)
+}
+
+func Test_CORS_WithCredentials_ShouldReflectTheOrigin(t *testing.T) {
+	cors := NewCORS().SetAllowedOrigins("https://app.example.com").SetAllowCredentials(true).SetAllowedHeaders("*").
+		SetExposedHeaders("X-Total-Count", "Link").SetMaxAge(10 * time.Minute)
+	checkCORS(t, cors, []corsCase{
+		{"simple request", "GET", map[string]string{"Origin": "https://app.example.com"}, http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers": "X-Total-Count, Link", "Vary": "Origin"}},
+		{"request without Origin", "GET", nil, http.StatusOK,
+			map[string]string{"Vary": "Origin"}},
+		{"request from another origin", "GET", map[string]string{"Origin": "https://evil.test"}, http.StatusOK,
+			map[string]string{"Vary": "Origin"}},
+		{"preflight", "OPTIONS", preflight("https://app.example.com", "PATCH", "Authorization", "X-Requested-With"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "https://app.example.com", "Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods": "PATCH", "Access-Control-Allow-Headers": "authorization, x-requested-with",
+				"Access-Control-Max-Age": "600"}},
+	})
+}
+
+func Test_CORS_WithCredentialsAndAnyOrigin_ShouldNotAllowTheCredentials(t *testing.T) {
+	checkCORS(t, NewCORS().SetAllowCredentials(true), []corsCase{
+		{"simple request", "GET", map[string]string{"Origin": "https://evil.test"}, http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Vary": ""}},
+		{"preflight", "OPTIONS", preflight("https://evil.test", "DELETE"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "DELETE"}},
+	})
+}
+
+func Test_CORS_WithPrivateNetwork_ShouldAllowIt(t *testing.T) {
+	cors := NewCORS().SetAllowedOrigins("https://example.com").SetAllowPrivateNetwork(true)
+	checkCORS(t, cors, []corsCase{
+		{"preflight requesting private network access", "OPTIONS", map[string]string{
+			"Origin": "https://example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Private-Network": "true"},
+			http.StatusNoContent, map[string]string{"Access-Control-Allow-Origin": "https://example.com", "Access-Control-Allow-Methods": "GET",
+				"Access-Control-Allow-Private-Network": "true", "Vary": preflightVary + ", Access-Control-Request-Private-Network"}},
+		{"preflight without private network access", "OPTIONS", preflight("https://example.com", "GET"), http.StatusNoContent,
+			map[string]string{"Access-Control-Allow-Origin": "https://example.com", "Access-Control-Allow-Methods": "GET"}},
+	})
+}
+
+func Test_CORS_WithOptions_ShouldConfigureThePreflightResponse(t *testing.T) {
+	checkCORS(t, NewCORS().SetOptionsSuccessStatus(http.StatusOK).SetAllowedMethods("purge"), []corsCase{
+		{"preflight", "OPTIONS", preflight("https://example.com", "PURGE"), http.StatusOK,
+			map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": "PURGE"}},
+		{"preflight of a method no longer allowed", "OPTIONS", preflight("https://example.com", "PUT"), http.StatusOK,
+			map[string]string{}},
+	})
+
+	called := false
+	handler := Chain(NewCORS().SetOptionsPassthrough(true).Handle).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		called = true
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("OPTIONS", "/", nil)
+	for name, value := range preflight("https://example.com", "PUT") {
+		request.Header.Set(name, value)
+	}
+	handler.ServeHTTP(recorder, request)
+	expect(t, called, true)
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Header().Get("Access-Control-Allow-Origin"), "*")
+}
+
+func Test_CORS_ShouldComposeWithTheOtherMiddlewares(t *testing.T) {
+	handler := Chain(NewCORS().Handle, NewContentTypeChecker().SetAcceptedContents("application/json").Check).Then(nil)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/", strings.NewReader("<html>"))
+	request.Header.Set("Origin", "https://example.com")
+	request.Header.Set("Content-Type", "text/html")
+	handler.ServeHTTP(recorder, request)
+	expect(t, recorder.Code, http.StatusUnsupportedMediaType)
+	expect(t, recorder.Header().Get("Access-Control-Allow-Origin"), "*")
+}
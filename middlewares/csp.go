@@ -0,0 +1,357 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/deliverous/cocktails/internal/cspnonce"
+)
+
+// CSPDirective is a directive of a Content-Security-Policy.
+type CSPDirective string
+
+const (
+	DefaultSrc     CSPDirective = "default-src"
+	ScriptSrc      CSPDirective = "script-src"
+	StyleSrc       CSPDirective = "style-src"
+	ImgSrc         CSPDirective = "img-src"
+	ConnectSrc     CSPDirective = "connect-src"
+	FontSrc        CSPDirective = "font-src"
+	ObjectSrc      CSPDirective = "object-src"
+	MediaSrc       CSPDirective = "media-src"
+	FrameSrc       CSPDirective = "frame-src"
+	WorkerSrc      CSPDirective = "worker-src"
+	ManifestSrc    CSPDirective = "manifest-src"
+	ChildSrc       CSPDirective = "child-src"
+	BaseURI        CSPDirective = "base-uri"
+	FormAction     CSPDirective = "form-action"
+	FrameAncestors CSPDirective = "frame-ancestors"
+	Sandbox        CSPDirective = "sandbox"
+)
+
+// CSPSource is a source of a Content-Security-Policy directive: a keyword, a scheme like "https:", or a host
+// like "https://cdn.example.com".
+type CSPSource string
+
+const (
+	SourceSelf          CSPSource = "'self'"
+	SourceNone          CSPSource = "'none'"
+	SourceUnsafeInline  CSPSource = "'unsafe-inline'"
+	SourceUnsafeEval    CSPSource = "'unsafe-eval'"
+	SourceStrictDynamic CSPSource = "'strict-dynamic'"
+	SourceData          CSPSource = "data:"
+	SourceBlob          CSPSource = "blob:"
+	SourceHTTPS         CSPSource = "https:"
+	// SourceNonce is replaced by the nonce of the request, like 'nonce-3q2+7w=='.
+	SourceNonce CSPSource = "'nonce'"
+)
+
+// CSPPolicy is a Content-Security-Policy:
+//
+//	policy := NewCSPPolicy().
+//		Directive(DefaultSrc, SourceSelf).
+//		Directive(ScriptSrc, SourceSelf, SourceNonce, SourceStrictDynamic).
+//		Directive(ObjectSrc, SourceNone).
+//		ReportURI("/csp-reports")
+type CSPPolicy struct {
+	directives []cspDirective
+	// endpoints are the Reporting-Endpoints of the report-to directive.
+	endpoints []string
+}
+
+type cspDirective struct {
+	name    CSPDirective
+	sources []CSPSource
+}
+
+// NewCSPPolicy creates an empty policy.
+func NewCSPPolicy() *CSPPolicy {
+	return &CSPPolicy{}
+}
+
+// Directive adds the sources to the directive.
+func (policy *CSPPolicy) Directive(name CSPDirective, sources ...CSPSource) *CSPPolicy {
+	for i := range policy.directives {
+		if policy.directives[i].name == name {
+			policy.directives[i].sources = append(policy.directives[i].sources, sources...)
+			return policy
+		}
+	}
+	policy.directives = append(policy.directives, cspDirective{name: name, sources: sources})
+	return policy
+}
+
+// UpgradeInsecureRequests adds the upgrade-insecure-requests directive.
+func (policy *CSPPolicy) UpgradeInsecureRequests() *CSPPolicy {
+	return policy.Directive("upgrade-insecure-requests")
+}
+
+// ReportURI adds the report-uri directive, where the browsers post the violation reports, see CSPReportHandler.
+func (policy *CSPPolicy) ReportURI(uri string) *CSPPolicy {
+	return policy.Directive("report-uri", CSPSource(uri))
+}
+
+// ReportTo adds the report-to directive, sending the violation reports to the endpoint of the Reporting API,
+// which is declared in the Reporting-Endpoints header of the responses. Parse the endpoint with url.Parse:
+//
+//	endpoint, err := url.Parse("https://example.com/csp-reports")
+//	policy.ReportTo("csp", endpoint)
+func (policy *CSPPolicy) ReportTo(name string, endpoint *url.URL) *CSPPolicy {
+	policy.endpoints = append(policy.endpoints, name+"="+structuredString(endpoint.String()))
+	return policy.Directive("report-to", CSPSource(name))
+}
+
+// structuredString returns value as a quoted string of a structured header field (RFC 8941): the quotes and the
+// backslashes are escaped, and the bytes which are not printable ASCII are percent encoded, as in the URLs.
+func structuredString(value string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"' || c == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(&builder, "%%%02X", c)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// String returns the policy with the nonce, if any.
+func (policy *CSPPolicy) String(nonce string) string {
+	parts := make([]string, len(policy.directives))
+	for i, directive := range policy.directives {
+		values := []string{string(directive.name)}
+		for _, source := range directive.sources {
+			if source == SourceNonce {
+				values = append(values, "'nonce-"+nonce+"'")
+			} else {
+				values = append(values, string(source))
+			}
+		}
+		parts[i] = strings.Join(values, " ")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ContentSecurityPolicy is a middleware adding the Content-Security-Policy header to the responses. A nonce is
+// generated for every request and stored in the request, so that the templates rendered by render.TemplateRender
+// get it with {{ cspNonce }}, and the handlers with CSPNonce or render.CSPNonce. The pages must be rendered with
+// TemplateRender.RenderRequest: without request, the templates calling {{ cspNonce }} fail.
+type ContentSecurityPolicy struct {
+	Policy *CSPPolicy
+	// ReportOnly defines whether the policy is only reported, with the Content-Security-Policy-Report-Only header.
+	// Default is false.
+	ReportOnly bool
+	// NonceSize is the number of random bytes of the nonces, which are encoded in URL safe base64, so that the
+	// templates do not escape them. A size below 16 is raised to 16, the minimum for the nonces to be unguessable.
+	// Default is 16.
+	NonceSize int
+}
+
+// NewContentSecurityPolicy creates a new ContentSecurityPolicy with default values.
+func NewContentSecurityPolicy(policy *CSPPolicy) *ContentSecurityPolicy {
+	return &ContentSecurityPolicy{
+		Policy:    policy,
+		NonceSize: minCSPNonceSize,
+	}
+}
+
+func (m *ContentSecurityPolicy) SetPolicy(policy *CSPPolicy) *ContentSecurityPolicy {
+	m.Policy = policy
+	return m
+}
+
+func (m *ContentSecurityPolicy) SetReportOnly(value bool) *ContentSecurityPolicy {
+	m.ReportOnly = value
+	return m
+}
+
+func (m *ContentSecurityPolicy) SetNonceSize(value int) *ContentSecurityPolicy {
+	m.NonceSize = nonceSize(value)
+	return m
+}
+
+// minCSPNonceSize is the minimum number of random bytes of the nonces.
+const minCSPNonceSize = 16
+
+func nonceSize(size int) int {
+	if size < minCSPNonceSize {
+		return minCSPNonceSize
+	}
+	return size
+}
+
+// Secure is the Middleware function to use in the chain. It responds with a StatusInternalServerError if no
+// nonce can be generated.
+func (m *ContentSecurityPolicy) Secure(next http.Handler) http.Handler {
+	header := "Content-Security-Policy"
+	if m.ReportOnly {
+		header = "Content-Security-Policy-Report-Only"
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		nonce := make([]byte, nonceSize(m.NonceSize))
+		if _, err := rand.Read(nonce); err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		encoded := base64.RawURLEncoding.EncodeToString(nonce)
+		writer.Header().Set(header, m.Policy.String(encoded))
+		if len(m.Policy.endpoints) > 0 {
+			writer.Header().Set("Reporting-Endpoints", strings.Join(m.Policy.endpoints, ", "))
+		}
+		next.ServeHTTP(writer, cspnonce.With(request, encoded))
+	})
+}
+
+// CSPNonce returns the Content-Security-Policy nonce generated for the request by ContentSecurityPolicy, empty if
+// none.
+func CSPNonce(request *http.Request) string {
+	return cspnonce.Get(request)
+}
+
+// CSPReport is a violation report of a Content-Security-Policy.
+type CSPReport struct {
+	DocumentURL        string
+	Referrer           string
+	BlockedURL         string
+	EffectiveDirective string
+	OriginalPolicy     string
+	Disposition        string
+	SourceFile         string
+	Sample             string
+	LineNumber         int
+	ColumnNumber       int
+	StatusCode         int
+}
+
+// legacyCSPReport is the body of a report sent to a report-uri, with the application/csp-report media type.
+type legacyCSPReport struct {
+	Report struct {
+		DocumentURI        string `json:"document-uri"`
+		Referrer           string `json:"referrer"`
+		BlockedURI         string `json:"blocked-uri"`
+		ViolatedDirective  string `json:"violated-directive"`
+		EffectiveDirective string `json:"effective-directive"`
+		OriginalPolicy     string `json:"original-policy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"source-file"`
+		ScriptSample       string `json:"script-sample"`
+		LineNumber         int    `json:"line-number"`
+		ColumnNumber       int    `json:"column-number"`
+		StatusCode         int    `json:"status-code"`
+	} `json:"csp-report"`
+}
+
+// reportingAPIReport is a report sent to a report-to endpoint, with the application/reports+json media type.
+type reportingAPIReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		Sample             string `json:"sample"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		StatusCode         int    `json:"statusCode"`
+	} `json:"body"`
+}
+
+// maxCSPReportSize is the maximum size of the body of the reports.
+const maxCSPReportSize = 64 * 1024
+
+// CSPReportHandler returns a handler collecting the violation reports posted by the browsers, to a report-uri
+// with the application/csp-report media type, or to a report-to endpoint with application/reports+json. The
+// reports are given to collect, and the handler responds with a StatusNoContent.
+func CSPReportHandler(collect func(request *http.Request, report CSPReport)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != "POST" {
+			writer.Header().Set("Allow", "POST")
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxCSPReportSize))
+		if err != nil {
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		reports, err := parseCSPReports(request.Header.Get("Content-Type"), data)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, report := range reports {
+			collect(request, report)
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func parseCSPReports(contentType string, data []byte) ([]CSPReport, error) {
+	mediatype, _, _ := mime.ParseMediaType(contentType)
+	if mediatype == "application/reports+json" {
+		var reports []reportingAPIReport
+		if err := json.Unmarshal(data, &reports); err != nil {
+			return nil, err
+		}
+		var result []CSPReport
+		for _, report := range reports {
+			if report.Type != "csp-violation" {
+				continue
+			}
+			body := report.Body
+			result = append(result, CSPReport{
+				DocumentURL:        body.DocumentURL,
+				Referrer:           body.Referrer,
+				BlockedURL:         body.BlockedURL,
+				EffectiveDirective: body.EffectiveDirective,
+				OriginalPolicy:     body.OriginalPolicy,
+				Disposition:        body.Disposition,
+				SourceFile:         body.SourceFile,
+				Sample:             body.Sample,
+				LineNumber:         body.LineNumber,
+				ColumnNumber:       body.ColumnNumber,
+				StatusCode:         body.StatusCode,
+			})
+		}
+		return result, nil
+	}
+
+	var legacy legacyCSPReport
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+	report := legacy.Report
+	directive := report.EffectiveDirective
+	if directive == "" {
+		directive = report.ViolatedDirective
+	}
+	return []CSPReport{{
+		DocumentURL:        report.DocumentURI,
+		Referrer:           report.Referrer,
+		BlockedURL:         report.BlockedURI,
+		EffectiveDirective: directive,
+		OriginalPolicy:     report.OriginalPolicy,
+		Disposition:        report.Disposition,
+		SourceFile:         report.SourceFile,
+		Sample:             report.ScriptSample,
+		LineNumber:         report.LineNumber,
+		ColumnNumber:       report.ColumnNumber,
+		StatusCode:         report.StatusCode,
+	}}, nil
+}
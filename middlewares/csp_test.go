@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+func Test_CSPPolicy_String_ShouldJoinTheDirectives(t *testing.T) {
+	policy := NewCSPPolicy().
+		Directive(DefaultSrc, SourceSelf).
+		Directive(ScriptSrc, SourceSelf, SourceNonce).
+		Directive(ImgSrc, SourceSelf).
+		Directive(ScriptSrc, SourceStrictDynamic).
+		Directive(ImgSrc, SourceData, "https://cdn.example.com").
+		UpgradeInsecureRequests().
+		ReportURI("/csp-reports")
+	expect(t, policy.String("abc"), "default-src 'self'; script-src 'self' 'nonce-abc' 'strict-dynamic'; "+
+		"img-src 'self' data: https://cdn.example.com; upgrade-insecure-requests; report-uri /csp-reports")
+}
+
+func Test_ContentSecurityPolicy_ShouldSetAFreshNoncePerRequest(t *testing.T) {
+	var nonces []string
+	csp := NewContentSecurityPolicy(NewCSPPolicy().Directive(ScriptSrc, SourceNonce))
+	handler := Chain(csp.Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		nonces = append(nonces, CSPNonce(request))
+	}))
+	first := processRequest(t, handler)
+	second := processRequest(t, handler)
+
+	expect(t, len(nonces), 2)
+	expect(t, len(nonces[0]), 22)
+	refute(t, nonces[0], nonces[1])
+	expect(t, first.Header().Get("Content-Security-Policy"), "script-src 'nonce-"+nonces[0]+"'")
+	expect(t, second.Header().Get("Content-Security-Policy"), "script-src 'nonce-"+nonces[1]+"'")
+}
+
+func Test_ContentSecurityPolicy_WithReportOnly_ShouldOnlyReport(t *testing.T) {
+	policy := NewCSPPolicy().Directive(DefaultSrc, SourceNone).ReportTo("csp", mustParseURL(t, "https://example.com/reports"))
+	header := processRequest(t, Chain(NewContentSecurityPolicy(policy).SetReportOnly(true).Secure).Then(nil)).Header()
+	expect(t, header.Get("Content-Security-Policy"), "")
+	expect(t, header.Get("Content-Security-Policy-Report-Only"), "default-src 'none'; report-to csp")
+	expect(t, header.Get("Reporting-Endpoints"), `csp="https://example.com/reports"`)
+}
+
+func Test_ContentSecurityPolicy_ShouldProvideTheNonceToTheTemplates(t *testing.T) {
+	templates := render.NewTemplateRender().SetFactory(render.NewStaticTemplateFactory("static").SetSubTemplates(func() (string, string) {
+		return "page", `<script nonce="{{ cspNonce }}">run()</script>`
+	}))
+	csp := NewContentSecurityPolicy(NewCSPPolicy().Directive(ScriptSrc, SourceNonce))
+	handler := Chain(csp.Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		templates.RenderRequest(writer, request, http.StatusOK, "page", nil)
+	}))
+	recorder := processRequest(t, handler)
+	nonce := strings.TrimSuffix(strings.TrimPrefix(recorder.Header().Get("Content-Security-Policy"), "script-src 'nonce-"), "'")
+	expect(t, recorder.Body.String(), `<script nonce="`+nonce+`">run()</script>`)
+}
+
+func postReport(handler http.Handler, method string, contentType string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, "/csp-reports", strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_CSPReportHandler_ShouldCollectTheLegacyReports(t *testing.T) {
+	var reports []CSPReport
+	handler := CSPReportHandler(func(request *http.Request, report CSPReport) { reports = append(reports, report) })
+	recorder := postReport(handler, "POST", "application/csp-report", `{"csp-report": {
+		"document-uri": "https://example.com/page", "blocked-uri": "inline", "violated-directive": "script-src-elem",
+		"original-policy": "script-src 'self'", "disposition": "enforce", "line-number": 12, "status-code": 200}}`)
+	expect(t, recorder.Code, http.StatusNoContent)
+	expect(t, len(reports), 1)
+	expect(t, reports[0].DocumentURL, "https://example.com/page")
+	expect(t, reports[0].BlockedURL, "inline")
+	expect(t, reports[0].EffectiveDirective, "script-src-elem")
+	expect(t, reports[0].LineNumber, 12)
+}
+
+func Test_CSPReportHandler_ShouldCollectTheReportingAPIReports(t *testing.T) {
+	var reports []CSPReport
+	handler := CSPReportHandler(func(request *http.Request, report CSPReport) { reports = append(reports, report) })
+	recorder := postReport(handler, "POST", "application/reports+json", `[
+		{"type": "csp-violation", "body": {"documentURL": "https://example.com/", "blockedURL": "eval", "effectiveDirective": "script-src", "disposition": "report"}},
+		{"type": "deprecation", "body": {}}]`)
+	expect(t, recorder.Code, http.StatusNoContent)
+	expect(t, len(reports), 1)
+	expect(t, reports[0].BlockedURL, "eval")
+	expect(t, reports[0].Disposition, "report")
+}
+
+func Test_CSPReportHandler_WithInvalidRequest_ShouldFail(t *testing.T) {
+	handler := CSPReportHandler(func(request *http.Request, report CSPReport) { t.Error("Unexpected report") })
+	expect(t, postReport(handler, "POST", "application/csp-report", "{").Code, http.StatusBadRequest)
+	expect(t, postReport(handler, "POST", "application/csp-report", strings.Repeat(" ", 65*1024)).Code, http.StatusRequestEntityTooLarge)
+	recorder := postReport(handler, "GET", "", "")
+	expect(t, recorder.Code, http.StatusMethodNotAllowed)
+	expect(t, recorder.Header().Get("Allow"), "POST")
+}
+
+func Test_ContentSecurityPolicy_WithASmallNonceSize_ShouldUseTheMinimumSize(t *testing.T) {
+	var nonces []string
+	policy := NewCSPPolicy().Directive(ScriptSrc, SourceNonce)
+	for _, csp := range []*ContentSecurityPolicy{
+		NewContentSecurityPolicy(policy).SetNonceSize(0),
+		NewContentSecurityPolicy(policy).SetNonceSize(-1),
+		{Policy: policy},
+	} {
+		processRequest(t, Chain(csp.Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			nonces = append(nonces, CSPNonce(request))
+		})))
+	}
+	expect(t, len(nonces), 3)
+	for _, nonce := range nonces {
+		expect(t, len(nonce), 22)
+	}
+	expect(t, NewContentSecurityPolicy(policy).SetNonceSize(32).NonceSize, 32)
+}
+
+func mustParseURL(t *testing.T, value string) *url.URL {
+	result, err := url.Parse(value)
+	expect(t, err, nil)
+	return result
+}
+
+func Test_CSPPolicy_ReportTo_ShouldQuoteTheEndpoint(t *testing.T) {
+	policy := NewCSPPolicy().
+		ReportTo("a", mustParseURL(t, `https://example.com/reports?x="y"&z=\`)).
+		ReportTo("b", mustParseURL(t, "https://example.com/a,b?é"))
+	header := processRequest(t, Chain(NewContentSecurityPolicy(policy).Secure).Then(nil)).Header()
+	expect(t, header.Get("Content-Security-Policy"), "report-to a b")
+	expect(t, header.Get("Reporting-Endpoints"), `a="https://example.com/reports?x=\"y\"&z=\\", b="https://example.com/a,b?%C3%A9"`)
+}
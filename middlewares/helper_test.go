@@ -21,3 +21,9 @@ func processRequest(t *testing.T, handler http.Handler) *httptest.ResponseRecord
 	handler.ServeHTTP(writer, request)
 	return writer
 }
+
+func refute(t *testing.T, value interface{}, expexted interface{}) {
+	if value == expexted {
+		t.Errorf("%#v not expected.", value)
+	}
+}
@@ -98,6 +98,8 @@ type Record struct {
 	URL       url.URL
 	Request   *http.Request
 	Writer    LoggingResponseWriter
+	// method is the method of the request received by the Logger, before the next handlers.
+	method string
 }
 
 func (record *Record) RemoteAddr() string {
@@ -145,6 +147,14 @@ func (record *Record) RequestUserAgent() string {
 	return agent
 }
 
+// OriginalMethod returns the method of the request before MethodOverride.
+func (record *Record) OriginalMethod() string {
+	if record.method != "" {
+		return record.method
+	}
+	return OriginalMethod(record.Request)
+}
+
 func (record *Record) Status() string {
 	return strconv.Itoa(record.Writer.Status())
 }
@@ -164,6 +174,7 @@ func (logger *Logger) Log(next http.Handler) http.Handler {
 			subWriter = &loggingWriter
 		}
 		url := *request.URL
+		method := request.Method
 		next.ServeHTTP(subWriter, request)
 		stop := logger.Timer()
 		writeLog(logger.Writer, logger.LogFunction, &Record{
@@ -172,6 +183,7 @@ func (logger *Logger) Log(next http.Handler) http.Handler {
 			URL:       url,
 			Request:   request,
 			Writer:    &loggingWriter,
+			method:    method,
 		})
 	})
 }
@@ -256,6 +268,13 @@ func RequestMethod() LogFunction {
 	}
 }
 
+// RequestOriginalMethod logs the method of the request before MethodOverride.
+func RequestOriginalMethod() LogFunction {
+	return func(buffer *bytes.Buffer, record *Record) {
+		buffer.WriteString(record.OriginalMethod())
+	}
+}
+
 func RequestURI() LogFunction {
 	return func(buffer *bytes.Buffer, record *Record) {
 		buffer.WriteString(record.URL.RequestURI())
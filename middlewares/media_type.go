@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"mime"
+	"strings"
+)
+
+// matchMediaType reports whether the media type, with its parameters as returned by mime.ParseMediaType, matches
+// the pattern. The pattern is a media type whose type or subtype can be "*", and whose subtype can be a
+// structured suffix (RFC 6839) like "*+json". The parameters of the pattern must all be present with the same
+// value; the other parameters of the media type are ignored. An invalid pattern matches nothing.
+func matchMediaType(pattern string, mediatype string, params map[string]string) bool {
+	patternType, patternParams, err := mime.ParseMediaType(pattern)
+	if err != nil {
+		return false
+	}
+	if !matchMediaRange(patternType, mediatype) {
+		return false
+	}
+	for name, expected := range patternParams {
+		value, ok := params[name]
+		if !ok {
+			return false
+		}
+		if name == "charset" {
+			if !strings.EqualFold(value, expected) {
+				return false
+			}
+		} else if value != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMediaRange compares the lower case type/subtype of a pattern and of a media type. A missing media type
+// matches nothing.
+func matchMediaRange(pattern string, mediatype string) bool {
+	if mediatype == "" {
+		return false
+	}
+	if pattern == mediatype || pattern == "*/*" || pattern == "*" {
+		return true
+	}
+	patternMain, patternSub := splitMediaType(pattern)
+	main, sub := splitMediaType(mediatype)
+	if patternMain != "*" && patternMain != main {
+		return false
+	}
+	switch {
+	case patternSub == "*":
+		return true
+	case strings.HasPrefix(patternSub, "*+"):
+		suffix := patternSub[1:]
+		return strings.HasSuffix(sub, suffix) && len(sub) > len(suffix)
+	default:
+		return patternSub == sub
+	}
+}
+
+func splitMediaType(mediatype string) (string, string) {
+	if slash := strings.Index(mediatype, "/"); slash >= 0 {
+		return mediatype[:slash], mediatype[slash+1:]
+	}
+	return mediatype, ""
+}
@@ -1,24 +1,162 @@
 package middlewares
 
 import (
+	"context"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
-// OverrideMethod is a middleware which checks for the X-HTTP-Method-Override header
-// or the _method form key, and overrides (if valid) request.Method with its value.
+// OverrideSource is a place of the request where MethodOverride looks for the method.
+type OverrideSource int
+
+const (
+	// HeaderSource is the header named MethodOverride.HeaderName.
+	HeaderSource OverrideSource = iota
+	// FormSource is the form field named MethodOverride.FieldName of an application/x-www-form-urlencoded body.
+	FormSource
+	// QuerySource is the query parameter named MethodOverride.FieldName.
+	QuerySource
+)
+
+// MethodOverride is a middleware which overrides the method of a request with the one found in a header, a form
+// field or a query parameter, when the method of the request and the new method are allowed. Only the
+// application/x-www-form-urlencoded bodies are parsed, so that the other bodies are left untouched.
+//
+// As the method is overridden in place, the middlewares placed before it in the chain, like Logger, see the
+// overridden method too. The next handlers get a copy of the request, in which the original method is available
+// with OriginalMethod.
+type MethodOverride struct {
+	// Sources are the places where the method is looked for, the first one found wins. Default is FormSource,
+	// then HeaderSource.
+	Sources []OverrideSource
+	// HeaderName is the header of HeaderSource. Default is X-HTTP-Method-Override.
+	HeaderName string
+	// FieldName is the form field of FormSource and the query parameter of QuerySource. Default is _method.
+	FieldName string
+	// OverriddenMethods are the methods which can be overridden. Default is POST: it isn't secure to override
+	// e.g a GET, which can be sent by a simple link, to a DELETE.
+	OverriddenMethods []string
+	// AllowedMethods are the methods which can replace the method of the request. Default is PUT, PATCH and DELETE.
+	AllowedMethods []string
+}
+
+// NewMethodOverride creates a new MethodOverride with default values.
+func NewMethodOverride() *MethodOverride {
+	return &MethodOverride{
+		Sources:           []OverrideSource{FormSource, HeaderSource},
+		HeaderName:        "X-HTTP-Method-Override",
+		FieldName:         "_method",
+		OverriddenMethods: []string{"POST"},
+		AllowedMethods:    []string{"PUT", "PATCH", "DELETE"},
+	}
+}
+
+func (m *MethodOverride) SetSources(sources ...OverrideSource) *MethodOverride {
+	m.Sources = sources
+	return m
+}
+
+func (m *MethodOverride) SetHeaderName(name string) *MethodOverride {
+	m.HeaderName = name
+	return m
+}
+
+func (m *MethodOverride) SetFieldName(name string) *MethodOverride {
+	m.FieldName = name
+	return m
+}
+
+func (m *MethodOverride) SetOverriddenMethods(methods ...string) *MethodOverride {
+	m.OverriddenMethods = upperCase(methods)
+	return m
+}
+
+func (m *MethodOverride) SetAllowedMethods(methods ...string) *MethodOverride {
+	m.AllowedMethods = upperCase(methods)
+	return m
+}
+
+// OverrideMethod is a middleware which checks for the _method form field or the X-HTTP-Method-Override header,
+// and overrides (if valid) request.Method with its value. See MethodOverride for the details.
 func OverrideMethod() Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-			if request.Method == "POST" {
-				method := request.FormValue("_method")
-				if method == "" {
-					method = request.Header.Get("X-HTTP-Method-Override")
-				}
-				if method == "PUT" || method == "PATCH" || method == "DELETE" {
-					request.Method = method
-				}
+	return NewMethodOverride().Override
+}
+
+type originalMethodKey struct{}
+
+// OriginalMethod returns the method of the request before MethodOverride, which is the method of the request
+// when it has not been overridden.
+func OriginalMethod(request *http.Request) string {
+	if method, ok := request.Context().Value(originalMethodKey{}).(string); ok {
+		return method
+	}
+	return request.Method
+}
+
+// Override is the Middleware function to use in the chain.
+func (m *MethodOverride) Override(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if contains(m.OverriddenMethods, request.Method) {
+			if method := strings.ToUpper(m.method(request)); method != "" && contains(m.AllowedMethods, method) {
+				original := request.Method
+				request.Method = method
+				request = request.WithContext(context.WithValue(request.Context(), originalMethodKey{}, original))
 			}
-			next.ServeHTTP(writer, request)
-		})
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// method returns the method found in the first source having one.
+func (m *MethodOverride) method(request *http.Request) string {
+	for _, source := range m.Sources {
+		var method string
+		switch source {
+		case HeaderSource:
+			method = request.Header.Get(m.HeaderName)
+		case FormSource:
+			method = formValues(request).Get(m.FieldName)
+		case QuerySource:
+			method = request.URL.Query().Get(m.FieldName)
+		}
+		if method != "" {
+			return method
+		}
+	}
+	return ""
+}
+
+// formValues returns the fields of the body of the request, parsing it only if it is
+// application/x-www-form-urlencoded. A form already parsed is used as is.
+func formValues(request *http.Request) url.Values {
+	if request.PostForm == nil && request.Form == nil {
+		mediatype, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		if mediatype != "application/x-www-form-urlencoded" {
+			return nil
+		}
+		request.ParseForm()
+	}
+	if request.PostForm != nil {
+		return request.PostForm
+	}
+	return request.Form
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func upperCase(values []string) []string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strings.ToUpper(value)
 	}
+	return result
 }
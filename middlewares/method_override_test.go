@@ -1,9 +1,12 @@
 package middlewares
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -64,6 +67,105 @@ func Test_OverrideMethod_WhenNotPostWithHeaderOverride_ShouldNotOverride(t *test
 	expectMethodIs(t, overrideDefinition{Method: "PUT", HeaderOverride: "POST"}, "PUT")
 }
 
+func Test_MethodOverride_ShouldOnlyParseURLEncodedForms(t *testing.T) {
+	var method, body string
+	handler := Chain(OverrideMethod()).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		data, _ := io.ReadAll(request.Body)
+		method, body = request.Method, string(data)
+	}))
+
+	request := httptest.NewRequest("POST", "/", strings.NewReader("_method=DELETE"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, method, "DELETE")
+
+	request = httptest.NewRequest("POST", "/", strings.NewReader("_method=DELETE"))
+	request.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, method, "POST")
+	expect(t, body, "_method=DELETE")
+}
+
+func Test_MethodOverride_WithSources_ShouldUseTheFirstFound(t *testing.T) {
+	var method string
+	override := NewMethodOverride().SetSources(QuerySource, HeaderSource).SetHeaderName("X-Method").SetFieldName("m")
+	handler := Chain(override.Override).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		method = request.Method
+	}))
+
+	request := httptest.NewRequest("POST", "/?m=PATCH", nil)
+	request.Header.Set("X-Method", "DELETE")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, method, "PATCH")
+
+	request = httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("X-Method", "delete")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, method, "DELETE")
+
+	request = httptest.NewRequest("POST", "/", strings.NewReader("m=PUT"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, method, "POST")
+}
+
+func Test_MethodOverride_WithAllowedMethods_ShouldOnlyOverrideToThem(t *testing.T) {
+	var method string
+	override := NewMethodOverride().SetOverriddenMethods("POST", "GET").SetAllowedMethods("query", "DELETE")
+	handler := Chain(override.Override).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		method = request.Method
+	}))
+	for _, test := range []struct{ method, override, expected string }{
+		{"POST", "QUERY", "QUERY"},
+		{"GET", "DELETE", "DELETE"},
+		{"POST", "PUT", "POST"},
+		{"PUT", "DELETE", "PUT"},
+	} {
+		request := httptest.NewRequest(test.method, "/", nil)
+		request.Header.Set("X-HTTP-Method-Override", test.override)
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+		expect(t, method, test.expected)
+	}
+}
+
+func Test_MethodOverride_ShouldRecordTheOriginalMethod(t *testing.T) {
+	var original string
+	handler := Chain(OverrideMethod()).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		original = OriginalMethod(request)
+	}))
+	request := httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("X-HTTP-Method-Override", "DELETE")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, original, "POST")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/", nil))
+	expect(t, original, "PUT")
+}
+
+func Test_MethodOverride_ShouldBeLoggedByAPreviousLogger(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	logger := NewLogger(Compose(RequestOriginalMethod(), ByteConstant(' '), RequestMethod())).SetWriter(buffer)
+	handler := Chain(logger.Log, OverrideMethod()).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	request := httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("X-HTTP-Method-Override", "PATCH")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	expect(t, buffer.String(), "POST PATCH\n")
+}
+
+func Test_MethodOverride_ShouldNotReplaceTheRequestOfThePreviousHandlers(t *testing.T) {
+	request := httptest.NewRequest("POST", "/", nil)
+	request.Header.Set("X-HTTP-Method-Override", "DELETE")
+	context := request.Context()
+	var received *http.Request
+	Chain(OverrideMethod()).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		received = request
+	})).ServeHTTP(httptest.NewRecorder(), request)
+	refute(t, received, request)
+	expect(t, received.Method, "DELETE")
+	expect(t, request.Method, "DELETE")
+	expect(t, request.Context(), context)
+}
+
 type overrideDefinition struct {
 	Method         string
 	HeaderOverride string
@@ -14,6 +14,9 @@ type Recovery struct {
 	PrintStack         bool
 	StackAllGoroutines bool
 	StackSize          int
+	// ErrorHandler, when set, writes the response instead of the default one. The panic value is converted to an
+	// error if needed. The panic is still logged.
+	ErrorHandler func(writer http.ResponseWriter, request *http.Request, err error)
 }
 
 // SetLogger defines the logger used by the recover handler to log errors.
@@ -40,6 +43,12 @@ func (recovery *Recovery) SetStackSize(value int) *Recovery {
 	return recovery
 }
 
+// SetErrorHandler defines the function writing the response of a panic, like render.ProblemRender.HandleError.
+func (recovery *Recovery) SetErrorHandler(handler func(writer http.ResponseWriter, request *http.Request, err error)) *Recovery {
+	recovery.ErrorHandler = handler
+	return recovery
+}
+
 // DefaultRecovery instanciates the Recovery middleware with default values.
 func NewRecovery() *Recovery {
 	return &Recovery{
@@ -55,13 +64,17 @@ func (recovery *Recovery) Recover(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				writer.WriteHeader(http.StatusInternalServerError)
 				stack := make([]byte, recovery.StackSize)
 				stack = stack[:runtime.Stack(stack, recovery.StackAllGoroutines)]
 
 				f := "PANIC: %s\n%s"
 				recovery.Logger.Printf(f, err, stack)
 
+				if recovery.ErrorHandler != nil {
+					recovery.ErrorHandler(writer, request, panicError(err))
+					return
+				}
+				writer.WriteHeader(http.StatusInternalServerError)
 				if recovery.PrintStack {
 					fmt.Fprintf(writer, f, err, stack)
 				}
@@ -70,3 +83,11 @@ func (recovery *Recovery) Recover(next http.Handler) http.Handler {
 		next.ServeHTTP(writer, request)
 	})
 }
+
+// panicError converts a panic value to an error.
+func panicError(value interface{}) error {
+	if err, ok := value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", value)
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/deliverous/cocktails/render"
 )
 
 var panicHandler = http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
@@ -73,3 +75,29 @@ func Test_WithRecovery_WithPrintStack_ShouldPrintTheStackToTheResponseBody(t *te
 		t.Error("Stack was not printed into the response")
 	}
 }
+
+func Test_WithRecovery_WithErrorHandler_ShouldRenderTheError(t *testing.T) {
+	buffer := bytes.NewBufferString("")
+	recovery := testRecoveryLoggingInto(buffer).SetErrorHandler(render.NewProblemRender().HandleError)
+	recorder := processRequest(t, Chain(recovery.Recover).Then(panicHandler))
+	expect(t, recorder.Code, http.StatusInternalServerError)
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"title":"Internal Server Error","status":500}`)
+	expect(t, strings.Contains(buffer.String(), "here is a panic!"), true)
+}
+
+func Test_WithRecovery_WithErrorHandler_ShouldConvertThePanicValueToAnError(t *testing.T) {
+	var received error
+	recovery := testRecovery().SetErrorHandler(func(writer http.ResponseWriter, request *http.Request, err error) {
+		received = err
+	})
+	processRequest(t, Chain(recovery.Recover).Then(panicHandler))
+	expect(t, received.Error(), "here is a panic!")
+
+	problem := render.NewProblem(http.StatusServiceUnavailable)
+	panicking := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		panic(problem)
+	})
+	processRequest(t, Chain(recovery.Recover).Then(panicking))
+	expect(t, received, error(problem))
+}
@@ -0,0 +1,222 @@
+package middlewares
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecurityHeaders is a middleware adding the security related headers to the responses. A header whose value is
+// empty is not set. The handlers can set or replace the headers, which are set before calling them.
+//
+// The RemovedHeaders, and the Headers whose value is empty, are removed just before the headers are written, so
+// that the ones set by the handlers or by the other middlewares, like PoweredBy, are removed too.
+type SecurityHeaders struct {
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header, which is not sent when zero. Default is
+	// 180 days. Browsers ignore this header when received over HTTP.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubDomains bool
+	HSTSPreload           bool
+	// ContentTypeOptions is the X-Content-Type-Options header. Default is nosniff.
+	ContentTypeOptions string
+	// FrameOptions is the X-Frame-Options header. Default is DENY.
+	FrameOptions string
+	// ReferrerPolicy is the Referrer-Policy header. Default is strict-origin-when-cross-origin.
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header, like "camera=(), geolocation=(self)". Default is empty.
+	PermissionsPolicy string
+	// CrossOriginOpenerPolicy is the Cross-Origin-Opener-Policy header. Default is same-origin.
+	CrossOriginOpenerPolicy string
+	// CrossOriginEmbedderPolicy is the Cross-Origin-Embedder-Policy header, like require-corp. Default is empty.
+	CrossOriginEmbedderPolicy string
+	// CrossOriginResourcePolicy is the Cross-Origin-Resource-Policy header. Default is same-origin.
+	CrossOriginResourcePolicy string
+	// Headers are additional headers. A header with an empty value is removed, like the RemovedHeaders.
+	Headers map[string]string
+	// RemovedHeaders are removed from the responses. Default is Server and X-Powered-By, to hide the server identity.
+	RemovedHeaders []string
+}
+
+// NewSecurityHeaders creates a new SecurityHeaders with default values.
+func NewSecurityHeaders() *SecurityHeaders {
+	return &SecurityHeaders{
+		HSTSMaxAge:                180 * 24 * time.Hour,
+		ContentTypeOptions:        "nosniff",
+		FrameOptions:              "DENY",
+		ReferrerPolicy:            "strict-origin-when-cross-origin",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginResourcePolicy: "same-origin",
+		RemovedHeaders:            []string{"Server", "X-Powered-By"},
+	}
+}
+
+// SetHSTS defines the Strict-Transport-Security header. A zero maxAge disables it.
+func (m *SecurityHeaders) SetHSTS(maxAge time.Duration, includeSubDomains bool, preload bool) *SecurityHeaders {
+	m.HSTSMaxAge = maxAge
+	m.HSTSIncludeSubDomains = includeSubDomains
+	m.HSTSPreload = preload
+	return m
+}
+
+func (m *SecurityHeaders) SetContentTypeOptions(value string) *SecurityHeaders {
+	m.ContentTypeOptions = value
+	return m
+}
+
+func (m *SecurityHeaders) SetFrameOptions(value string) *SecurityHeaders {
+	m.FrameOptions = value
+	return m
+}
+
+func (m *SecurityHeaders) SetReferrerPolicy(value string) *SecurityHeaders {
+	m.ReferrerPolicy = value
+	return m
+}
+
+func (m *SecurityHeaders) SetPermissionsPolicy(value string) *SecurityHeaders {
+	m.PermissionsPolicy = value
+	return m
+}
+
+func (m *SecurityHeaders) SetCrossOriginOpenerPolicy(value string) *SecurityHeaders {
+	m.CrossOriginOpenerPolicy = value
+	return m
+}
+
+func (m *SecurityHeaders) SetCrossOriginEmbedderPolicy(value string) *SecurityHeaders {
+	m.CrossOriginEmbedderPolicy = value
+	return m
+}
+
+func (m *SecurityHeaders) SetCrossOriginResourcePolicy(value string) *SecurityHeaders {
+	m.CrossOriginResourcePolicy = value
+	return m
+}
+
+// SetHeader adds a header to the responses. An empty value removes the header.
+func (m *SecurityHeaders) SetHeader(name string, value string) *SecurityHeaders {
+	if m.Headers == nil {
+		m.Headers = make(map[string]string)
+	}
+	m.Headers[name] = value
+	return m
+}
+
+// RemoveHeaders adds headers to remove from the responses.
+func (m *SecurityHeaders) RemoveHeaders(names ...string) *SecurityHeaders {
+	m.RemovedHeaders = append(m.RemovedHeaders, names...)
+	return m
+}
+
+// hsts returns the value of the Strict-Transport-Security header.
+func (m *SecurityHeaders) hsts() string {
+	if m.HSTSMaxAge <= 0 {
+		return ""
+	}
+	value := "max-age=" + strconv.FormatInt(int64(m.HSTSMaxAge/time.Second), 10)
+	if m.HSTSIncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if m.HSTSPreload {
+		value += "; preload"
+	}
+	return value
+}
+
+// Secure is the Middleware function to use in the chain.
+func (m *SecurityHeaders) Secure(next http.Handler) http.Handler {
+	headers := map[string]string{
+		"Strict-Transport-Security":    m.hsts(),
+		"X-Content-Type-Options":       m.ContentTypeOptions,
+		"X-Frame-Options":              m.FrameOptions,
+		"Referrer-Policy":              m.ReferrerPolicy,
+		"Permissions-Policy":           m.PermissionsPolicy,
+		"Cross-Origin-Opener-Policy":   m.CrossOriginOpenerPolicy,
+		"Cross-Origin-Embedder-Policy": m.CrossOriginEmbedderPolicy,
+		"Cross-Origin-Resource-Policy": m.CrossOriginResourcePolicy,
+	}
+	for name, value := range m.Headers {
+		headers[name] = value
+	}
+	removed := append([]string{}, m.RemovedHeaders...)
+	for name, value := range m.Headers {
+		if value == "" {
+			removed = append(removed, name)
+		}
+	}
+	for name, value := range headers {
+		if value == "" {
+			delete(headers, name)
+		}
+	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		header := writer.Header()
+		for name, value := range headers {
+			header.Set(name, value)
+		}
+		if len(removed) == 0 {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		hooked := &headerHookWriter{ResponseWriter: writer, hook: func(header http.Header) {
+			for _, name := range removed {
+				header.Del(name)
+			}
+		}}
+		next.ServeHTTP(hooked, request)
+		if !hooked.wroteHeader {
+			// Nothing written: the server writes the headers after the handler returns.
+			hooked.hook(header)
+		}
+	})
+}
+
+// headerHookWriter calls hook with the headers just before they are written.
+type headerHookWriter struct {
+	http.ResponseWriter
+	hook        func(http.Header)
+	wroteHeader bool
+}
+
+func (writer *headerHookWriter) WriteHeader(status int) {
+	if !writer.wroteHeader {
+		writer.wroteHeader = true
+		writer.hook(writer.Header())
+	}
+	writer.ResponseWriter.WriteHeader(status)
+}
+
+func (writer *headerHookWriter) Write(b []byte) (int, error) {
+	if !writer.wroteHeader {
+		writer.WriteHeader(http.StatusOK)
+	}
+	return writer.ResponseWriter.Write(b)
+}
+
+// Provided in order to implement the http.Flusher interface.
+func (writer *headerHookWriter) Flush() {
+	if !writer.wroteHeader {
+		writer.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Provided in order to implement the http.Hijacker interface.
+func (writer *headerHookWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := writer.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middlewares: the response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (writer *headerHookWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_SecurityHeaders_ShouldSetTheDefaultHeaders(t *testing.T) {
+	recorder := processRequest(t, Chain(NewSecurityHeaders().Secure).Then(nil))
+	header := recorder.Header()
+	expect(t, header.Get("Strict-Transport-Security"), "max-age=15552000")
+	expect(t, header.Get("X-Content-Type-Options"), "nosniff")
+	expect(t, header.Get("X-Frame-Options"), "DENY")
+	expect(t, header.Get("Referrer-Policy"), "strict-origin-when-cross-origin")
+	expect(t, header.Get("Cross-Origin-Opener-Policy"), "same-origin")
+	expect(t, header.Get("Cross-Origin-Resource-Policy"), "same-origin")
+	expect(t, len(header.Values("Permissions-Policy")), 0)
+	expect(t, len(header.Values("Cross-Origin-Embedder-Policy")), 0)
+}
+
+func Test_SecurityHeaders_WithSetters_ShouldSetTheHeaders(t *testing.T) {
+	headers := NewSecurityHeaders().
+		SetHSTS(365*24*time.Hour, true, true).
+		SetFrameOptions("SAMEORIGIN").
+		SetReferrerPolicy("no-referrer").
+		SetPermissionsPolicy("camera=(), geolocation=(self)").
+		SetCrossOriginEmbedderPolicy("require-corp").
+		SetCrossOriginOpenerPolicy("same-origin-allow-popups").
+		SetCrossOriginResourcePolicy("cross-origin").
+		SetContentTypeOptions("").
+		SetHeader("X-Permitted-Cross-Domain-Policies", "none")
+	header := processRequest(t, Chain(headers.Secure).Then(nil)).Header()
+	expect(t, header.Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains; preload")
+	expect(t, header.Get("X-Frame-Options"), "SAMEORIGIN")
+	expect(t, header.Get("Referrer-Policy"), "no-referrer")
+	expect(t, header.Get("Permissions-Policy"), "camera=(), geolocation=(self)")
+	expect(t, header.Get("Cross-Origin-Embedder-Policy"), "require-corp")
+	expect(t, header.Get("Cross-Origin-Opener-Policy"), "same-origin-allow-popups")
+	expect(t, header.Get("Cross-Origin-Resource-Policy"), "cross-origin")
+	expect(t, header.Get("X-Permitted-Cross-Domain-Policies"), "none")
+	expect(t, len(header.Values("X-Content-Type-Options")), 0)
+}
+
+func Test_SecurityHeaders_WithZeroMaxAge_ShouldNotSendHSTS(t *testing.T) {
+	header := processRequest(t, Chain(NewSecurityHeaders().SetHSTS(0, true, false).Secure).Then(nil)).Header()
+	expect(t, len(header.Values("Strict-Transport-Security")), 0)
+}
+
+func Test_SecurityHeaders_ShouldRemoveTheServerIdentity(t *testing.T) {
+	handler := Chain(PoweredBy("cocktails"), NewSecurityHeaders().Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Server", "cocktails/1.0")
+		writer.Write([]byte("body"))
+	}))
+	header := processRequest(t, handler).Header()
+	expect(t, len(header.Values("X-Powered-By")), 0)
+	expect(t, len(header.Values("Server")), 0)
+}
+
+func Test_SecurityHeaders_WithRemovedHeaders_ShouldRemoveThem(t *testing.T) {
+	handler := Chain(NewSecurityHeaders().RemoveHeaders("X-Debug").Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("X-Debug", "secret")
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	recorder := processRequest(t, handler)
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, len(recorder.Header().Values("X-Debug")), 0)
+}
+
+func Test_SecurityHeaders_ShouldLetTheHandlersReplaceTheHeaders(t *testing.T) {
+	handler := Chain(NewSecurityHeaders().Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		writer.(http.Flusher).Flush()
+	}))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+	expect(t, recorder.Header().Get("X-Frame-Options"), "SAMEORIGIN")
+	expect(t, recorder.Flushed, true)
+}
+
+func Test_SecurityHeaders_WhenNothingIsWritten_ShouldRemoveTheHeaders(t *testing.T) {
+	header := processRequest(t, Chain(PoweredBy("cocktails"), NewSecurityHeaders().Secure).Then(nil)).Header()
+	expect(t, len(header.Values("X-Powered-By")), 0)
+}
+
+func Test_SecurityHeaders_WithoutValue_ShouldLetTheHandlersSetTheHeader(t *testing.T) {
+	headers := NewSecurityHeaders().SetHSTS(0, false, false).SetFrameOptions("")
+	handler := Chain(headers.Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Permissions-Policy", "camera=()")
+		writer.Header().Set("Strict-Transport-Security", "max-age=60")
+		writer.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		writer.Write([]byte("body"))
+	}))
+	header := processRequest(t, handler).Header()
+	expect(t, header.Get("Permissions-Policy"), "camera=()")
+	expect(t, header.Get("Strict-Transport-Security"), "max-age=60")
+	expect(t, header.Get("X-Frame-Options"), "SAMEORIGIN")
+}
+
+func Test_SecurityHeaders_WithAnEmptyHeader_ShouldRemoveIt(t *testing.T) {
+	handler := Chain(NewSecurityHeaders().SetHeader("X-Frame-Options", "").Secure).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		writer.Write([]byte("body"))
+	}))
+	expect(t, len(processRequest(t, handler).Header().Values("X-Frame-Options")), 0)
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// errInvalidBody is returned when a body contains a byte sequence which is invalid in its charset.
+var errInvalidBody = errors.New("invalid byte sequence")
+
+// charsetEncoding returns the encoding of an IANA charset name, nil for UTF-8 which needs no transcoding.
+func charsetEncoding(charset string) (encoding.Encoding, error) {
+	if strings.EqualFold(charset, "UTF-8") || strings.EqualFold(charset, "UTF8") {
+		return nil, nil
+	}
+	result, err := ianaindex.IANA.Encoding(charset)
+	if err == nil && result == nil {
+		err = fmt.Errorf("unsupported charset %q", charset)
+	}
+	return result, err
+}
+
+// canonicalCharset returns the IANA name of a charset, the charset itself if unknown.
+func canonicalCharset(charset string) string {
+	if strings.EqualFold(charset, "UTF8") {
+		return "UTF-8"
+	}
+	if encoding, err := ianaindex.IANA.Encoding(charset); err == nil && encoding != nil {
+		if name, err := ianaindex.IANA.Name(encoding); err == nil {
+			return name
+		}
+	}
+	return charset
+}
+
+// transcode converts data to UTF-8. As the decoders replace the invalid sequences by U+FFFD, a result containing
+// U+FFFD is encoded back to detect them.
+func transcode(data []byte, charset encoding.Encoding) ([]byte, error) {
+	result, err := charset.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, errInvalidBody
+	}
+	if bytes.ContainsRune(result, utf8.RuneError) {
+		original, err := charset.NewEncoder().Bytes(result)
+		if err != nil || !bytes.Equal(original, data) {
+			return nil, errInvalidBody
+		}
+	}
+	return result, nil
+}
+
+// transcodeRequest returns a copy of the request whose body is converted from the charset to UTF-8, and whose
+// Content-Type charset parameter is UTF-8. A body larger than max bytes, unless negative, fails with a
+// *http.MaxBytesError.
+func transcodeRequest(request *http.Request, mediatype string, params map[string]string, charset encoding.Encoding, max int64) (*http.Request, error) {
+	body := io.Reader(request.Body)
+	if max >= 0 {
+		body = io.LimitReader(body, max+1)
+	}
+	data, err := io.ReadAll(body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if max >= 0 && int64(len(data)) > max {
+		return nil, &http.MaxBytesError{Limit: max}
+	}
+	if data, err = transcode(data, charset); err != nil {
+		return nil, err
+	}
+
+	result := request.Clone(request.Context())
+	result.Body = io.NopCloser(bytes.NewReader(data))
+	result.ContentLength = int64(len(data))
+	result.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	converted := make(map[string]string, len(params)+1)
+	for name, value := range params {
+		converted[name] = value
+	}
+	converted["charset"] = "UTF-8"
+	result.Header.Set("Content-Type", mime.FormatMediaType(mediatype, converted))
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type receivedRequest struct {
+	contentType   string
+	contentLength int64
+	body          string
+}
+
+func transcodeHandler(received *receivedRequest) http.Handler {
+	checker := NewContentTypeChecker().
+		SetAcceptedCharset("UTF-8", "ISO-8859-1", "windows-1252", "Shift_JIS").
+		SetTranscode(true)
+	return Chain(checker.Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		*received = receivedRequest{request.Header.Get("Content-Type"), request.ContentLength, string(body)}
+	}))
+}
+
+func postBody(handler http.Handler, contentType string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func Test_Transcode_ShouldConvertTheBodyToUTF8(t *testing.T) {
+	var received receivedRequest
+	handler := transcodeHandler(&received)
+
+	recorder := postBody(handler, "text/plain; charset=iso-8859-1; format=flowed", "caf\xe9")
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, received.body, "café")
+	expect(t, received.contentLength, int64(5))
+	expect(t, received.contentType, "text/plain; charset=UTF-8; format=flowed")
+
+	postBody(handler, "application/json; charset=windows-1252", "\"\x80 \x93quoted\x94\"")
+	expect(t, received.body, "\"€ “quoted”\"")
+}
+
+func Test_Transcode_WithACharsetAlias_ShouldAcceptIt(t *testing.T) {
+	var received receivedRequest
+	handler := transcodeHandler(&received)
+	for _, charset := range []string{"latin1", "iso_8859-1", "csISOLatin1"} {
+		received = receivedRequest{}
+		recorder := postBody(handler, "text/plain; charset="+charset, "caf\xe9")
+		expect(t, recorder.Code, http.StatusOK)
+		expect(t, received.body, "café")
+	}
+
+	checker := NewContentTypeChecker().SetAcceptedCharset("latin1", "utf8").SetTranscode(true)
+	recorder := postBody(Chain(checker.Check).Then(nil), "text/plain; charset=ISO-8859-1", "caf\xe9")
+	expect(t, recorder.Code, http.StatusOK)
+	recorder = postBody(Chain(checker.Check).Then(nil), "text/plain; charset=UTF-8", "café")
+	expect(t, recorder.Code, http.StatusOK)
+}
+
+func Test_Transcode_WithUTF8_ShouldKeepTheBody(t *testing.T) {
+	var received receivedRequest
+	postBody(transcodeHandler(&received), "text/plain; charset=utf-8", "café")
+	expect(t, received.body, "café")
+	expect(t, received.contentType, "text/plain; charset=utf-8")
+}
+
+func Test_Transcode_ShouldUseTheAssumedCharsetForTextualContents(t *testing.T) {
+	var received receivedRequest
+	checker := NewContentTypeChecker().SetAcceptedCharset("ISO-8859-1").SetAssumedCharset("ISO-8859-1").SetTranscode(true)
+	handler := Chain(checker.Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		received = receivedRequest{request.Header.Get("Content-Type"), request.ContentLength, string(body)}
+	}))
+
+	postBody(handler, "application/x-www-form-urlencoded", "name=Ren\xe9")
+	expect(t, received.body, "name=René")
+	expect(t, received.contentType, "application/x-www-form-urlencoded; charset=UTF-8")
+
+	postBody(handler, "image/png", "\x89PNG\xe9")
+	expect(t, received.body, "\x89PNG\xe9")
+	expect(t, received.contentType, "image/png")
+}
+
+func Test_Transcode_WithInvalidByteSequence_ShouldRespondBadRequest(t *testing.T) {
+	var received receivedRequest
+	recorder := postBody(transcodeHandler(&received), "text/plain; charset=Shift_JIS", "\x82")
+	expect(t, recorder.Code, http.StatusBadRequest)
+	expect(t, received.body, "")
+}
+
+func Test_Transcode_WithUnknownCharset_ShouldReject(t *testing.T) {
+	checker := NewContentTypeChecker().SetAcceptedCharset().SetTranscode(true)
+	recorder := postBody(Chain(checker.Check).Then(nil), "text/plain; charset=klingon", "body")
+	expect(t, recorder.Code, http.StatusUnsupportedMediaType)
+}
+
+func Test_Transcode_WithoutTranscode_ShouldKeepTheBody(t *testing.T) {
+	var body string
+	checker := NewContentTypeChecker().SetAcceptedCharset("ISO-8859-1")
+	handler := Chain(checker.Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		data, _ := io.ReadAll(request.Body)
+		body = string(data)
+	}))
+	postBody(handler, "text/plain; charset=ISO-8859-1", "caf\xe9")
+	expect(t, body, "caf\xe9")
+}
+
+func Test_Transcode_WithInvalidByteSequence_ShouldDescribeTheProblem(t *testing.T) {
+	var received receivedRequest
+	recorder := postBody(transcodeHandler(&received), "text/plain; charset=Shift_JIS", "\x82")
+	expect(t, recorder.Body.String(), `{"title":"Bad Request","status":400,"detail":"the body is not valid Shift_JIS",`+
+		`"acceptedCharsets":["UTF-8","ISO-8859-1","WINDOWS-1252","SHIFT_JIS"],"reason":"invalid-body"}`)
+}
+
+func Test_Transcode_WithABodyAboveTheMaxSize_ShouldRespondRequestEntityTooLarge(t *testing.T) {
+	var received receivedRequest
+	checker := NewContentTypeChecker().SetAcceptedCharset("ISO-8859-1").SetTranscode(true).SetMaxTranscodedSize(4)
+	handler := Chain(checker.Check).Then(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		received = receivedRequest{request.Header.Get("Content-Type"), request.ContentLength, string(body)}
+	}))
+
+	postBody(handler, "text/plain; charset=ISO-8859-1", "caf\xe9")
+	expect(t, received.body, "café")
+
+	received = receivedRequest{}
+	recorder := postBody(handler, "text/plain; charset=ISO-8859-1", "caf\xe9s")
+	expect(t, recorder.Code, http.StatusRequestEntityTooLarge)
+	expect(t, strings.Contains(recorder.Body.String(), `"detail":"the body exceeds 4 bytes"`), true)
+	expect(t, received.body, "")
+}
@@ -0,0 +1,26 @@
+// Package binary provides the renderers of the binary encodings, MessagePack, CBOR and Protocol Buffers.
+// They are kept out of the render package, so that its users do not depend on their encoders.
+package binary
+
+import (
+	"net/http"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+var (
+	_ render.Renderer = (*CBORRender)(nil)
+	_ render.Renderer = (*MsgPackRender)(nil)
+	_ render.Renderer = (*ProtobufRender)(nil)
+)
+
+// encodeError wraps an error of an encoder, like the renderers of the render package.
+func encodeError(err error) error {
+	return &render.RenderError{Phase: render.EncodePhase, Err: err}
+}
+
+// write sets the Content-Type header, without charset, and writes the encoded value.
+func write(writer http.ResponseWriter, status int, contentType string, policy render.HeaderPolicy, data []byte) error {
+	renderer := &render.DataRender{ContentType: contentType, HeaderPolicy: policy}
+	return renderer.Render(writer, status, data)
+}
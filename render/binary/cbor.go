@@ -0,0 +1,60 @@
+package binary
+
+import (
+	"net/http"
+
+	"github.com/deliverous/cocktails/render"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOR built-in renderer.
+type CBORRender struct {
+	ContentType  string
+	HeaderPolicy render.HeaderPolicy
+	// If Deterministic is set to true, the value is encoded following the core deterministic encoding
+	// requirements of RFC 8949, with sorted map keys. Default is false.
+	Deterministic bool
+}
+
+func (renderer *CBORRender) SetContentType(value string) *CBORRender {
+	renderer.ContentType = value
+	return renderer
+}
+
+func (renderer *CBORRender) SetHeaderPolicy(value render.HeaderPolicy) *CBORRender {
+	renderer.HeaderPolicy = value
+	return renderer
+}
+
+func (renderer *CBORRender) SetDeterministic(value bool) *CBORRender {
+	renderer.Deterministic = value
+	return renderer
+}
+
+// NewCBORRender creates a new CBOR render with default values.
+func NewCBORRender() *CBORRender {
+	return &CBORRender{
+		ContentType: "application/cbor",
+	}
+}
+
+var deterministicCBOR, _ = cbor.CoreDetEncOptions().EncMode()
+
+// Render a CBOR response.
+func (renderer *CBORRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	marshal := cbor.Marshal
+	if renderer.Deterministic {
+		marshal = deterministicCBOR.Marshal
+	}
+	result, err := marshal(v)
+	if err != nil {
+		return encodeError(err)
+	}
+
+	return write(writer, status, renderer.ContentType, renderer.HeaderPolicy, result)
+}
+
+// Respond implements the render.Renderer interface.
+func (renderer *CBORRender) Respond(writer http.ResponseWriter, status int, v interface{}, options render.Options) error {
+	return renderer.Render(writer, status, v)
+}
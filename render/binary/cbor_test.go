@@ -0,0 +1,48 @@
+package binary
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+	"github.com/fxamacker/cbor/v2"
+)
+
+func Test_CBOR_Render_ShouldRoundTrip(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCBORRender().Render(recorder, http.StatusOK, binaryGreeting())
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/cbor")
+
+	var result BinaryGreeting
+	expect(t, cbor.Unmarshal(recorder.Body.Bytes(), &result), nil)
+	expect(t, result.Two, "world")
+	expect(t, result.Count, 42)
+	expect(t, result.Tags["a"], "1")
+}
+
+func Test_CBOR_Render_WithDeterministicEncoding_ShouldAlwaysProduceTheSameBytes(t *testing.T) {
+	values := map[string]int{}
+	for _, key := range []string{"zeta", "alpha", "mu", "beta", "omega", "pi", "rho"} {
+		values[key] = len(key)
+	}
+	var first []byte
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		NewCBORRender().SetDeterministic(true).Render(recorder, http.StatusOK, values)
+		if first == nil {
+			first = recorder.Body.Bytes()
+		}
+		expect(t, bytes.Equal(first, recorder.Body.Bytes()), true)
+	}
+}
+
+func Test_CBOR_Render_WhenEncodingFails_ShouldWriteNothing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCBORRender().Render(recorder, http.StatusOK, func() {})
+	expectPhase(t, err, render.EncodePhase)
+	expect(t, recorder.Body.Len(), 0)
+	expect(t, recorder.Header().Get("Content-Type"), "")
+}
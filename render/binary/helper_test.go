@@ -0,0 +1,36 @@
+package binary
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+)
+
+func expect(t *testing.T, value interface{}, expexted interface{}) {
+	if value != expexted {
+		t.Errorf("Expected %#v, got %#v.", expexted, value)
+	}
+}
+
+func refute(t *testing.T, value interface{}, expexted interface{}) {
+	if value == expexted {
+		t.Errorf("%#v not expected.", value)
+	}
+}
+
+func expectPhase(t *testing.T, err error, phase render.Phase) {
+	var renderError *render.RenderError
+	if !errors.As(err, &renderError) {
+		t.Errorf("Expected a RenderError, got %#v.", err)
+		return
+	}
+	expect(t, renderError.Phase, phase)
+}
+
+func respond(renderer render.Renderer, status int, v interface{}, options render.Options) (*httptest.ResponseRecorder, error) {
+	recorder := httptest.NewRecorder()
+	err := renderer.Respond(recorder, status, v, options)
+	return recorder, err
+}
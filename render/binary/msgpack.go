@@ -0,0 +1,59 @@
+package binary
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/deliverous/cocktails/render"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePack built-in renderer.
+type MsgPackRender struct {
+	ContentType  string
+	HeaderPolicy render.HeaderPolicy
+	// If UseJSONTag is set to true, the struct fields are named after their json tag when they have no msgpack tag,
+	// so that the structs rendered in JSON are rendered in MessagePack with the same names. Default is false.
+	UseJSONTag bool
+}
+
+func (renderer *MsgPackRender) SetContentType(value string) *MsgPackRender {
+	renderer.ContentType = value
+	return renderer
+}
+
+func (renderer *MsgPackRender) SetHeaderPolicy(value render.HeaderPolicy) *MsgPackRender {
+	renderer.HeaderPolicy = value
+	return renderer
+}
+
+func (renderer *MsgPackRender) SetUseJSONTag(value bool) *MsgPackRender {
+	renderer.UseJSONTag = value
+	return renderer
+}
+
+// NewMsgPackRender creates a new MessagePack render with default values.
+func NewMsgPackRender() *MsgPackRender {
+	return &MsgPackRender{
+		ContentType: "application/msgpack",
+	}
+}
+
+// Render a MessagePack response.
+func (renderer *MsgPackRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	buffer := new(bytes.Buffer)
+	encoder := msgpack.NewEncoder(buffer)
+	if renderer.UseJSONTag {
+		encoder.SetCustomStructTag("json")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return encodeError(err)
+	}
+
+	return write(writer, status, renderer.ContentType, renderer.HeaderPolicy, buffer.Bytes())
+}
+
+// Respond implements the render.Renderer interface.
+func (renderer *MsgPackRender) Respond(writer http.ResponseWriter, status int, v interface{}, options render.Options) error {
+	return renderer.Render(writer, status, v)
+}
@@ -0,0 +1,69 @@
+package binary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type BinaryGreeting struct {
+	One   string            `json:"one" cbor:"one"`
+	Two   string            `json:"two" cbor:"two"`
+	Count int               `json:"count" cbor:"count"`
+	Tags  map[string]string `json:"tags" cbor:"tags"`
+}
+
+func binaryGreeting() BinaryGreeting {
+	return BinaryGreeting{One: "hello", Two: "world", Count: 42, Tags: map[string]string{"a": "1", "b": "2"}}
+}
+
+func Test_MsgPack_Render_ShouldRoundTrip(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewMsgPackRender().Render(recorder, http.StatusCreated, binaryGreeting())
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Header().Get("Content-Type"), "application/msgpack")
+
+	var result BinaryGreeting
+	expect(t, msgpack.Unmarshal(recorder.Body.Bytes(), &result), nil)
+	expect(t, result.One, "hello")
+	expect(t, result.Count, 42)
+	expect(t, result.Tags["b"], "2")
+}
+
+func Test_MsgPack_Render_WithJSONTag_ShouldUseTheJSONNames(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewMsgPackRender().SetUseJSONTag(true).Render(recorder, http.StatusOK, binaryGreeting())
+	var result map[string]interface{}
+	expect(t, msgpack.Unmarshal(recorder.Body.Bytes(), &result), nil)
+	expect(t, result["one"], "hello")
+
+	recorder = httptest.NewRecorder()
+	NewMsgPackRender().Render(recorder, http.StatusOK, binaryGreeting())
+	result = nil
+	msgpack.Unmarshal(recorder.Body.Bytes(), &result)
+	expect(t, result["One"], "hello")
+}
+
+func Test_MsgPack_Render_WhenContentTypeAlreadySet_ShouldFollowTheHeaderPolicy(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewMsgPackRender().Render(recorder, http.StatusNotFound, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "bla")
+	expect(t, recorder.Code, http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewMsgPackRender().SetContentType("application/vnd.msgpack").SetHeaderPolicy(render.OverrideContentType).Render(recorder, http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/vnd.msgpack")
+}
+
+func Test_MsgPack_Render_WhenEncodingFails_ShouldWriteNothing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewMsgPackRender().Render(recorder, http.StatusOK, make(chan int))
+	expectPhase(t, err, render.EncodePhase)
+	expect(t, recorder.Body.Len(), 0)
+}
@@ -0,0 +1,39 @@
+package binary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func Test_Negotiation_Respond_ShouldRenderWithTheBinaryRenderers(t *testing.T) {
+	negotiation := render.NewNegotiation().
+		Offer("application/json", render.NewJSONRender()).
+		Offer("application/msgpack", NewMsgPackRender()).
+		Offer("application/cbor", NewCBORRender())
+	request := func(accept string) *http.Request {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.Header.Set("Accept", accept)
+		return request
+	}
+
+	recorder := httptest.NewRecorder()
+	err := negotiation.Respond(recorder, request("application/msgpack"), http.StatusOK, binaryGreeting(), render.Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/msgpack")
+	expect(t, recorder.Header().Get("Vary"), "Accept")
+	var fromMsgPack BinaryGreeting
+	expect(t, msgpack.Unmarshal(recorder.Body.Bytes(), &fromMsgPack), nil)
+	expect(t, fromMsgPack.Count, 42)
+
+	recorder = httptest.NewRecorder()
+	negotiation.Respond(recorder, request("application/cbor"), http.StatusOK, binaryGreeting(), render.Options{})
+	expect(t, recorder.Header().Get("Content-Type"), "application/cbor")
+	var fromCBOR BinaryGreeting
+	expect(t, cbor.Unmarshal(recorder.Body.Bytes(), &fromCBOR), nil)
+	expect(t, fromCBOR.Tags["a"], "1")
+}
@@ -0,0 +1,58 @@
+package binary
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/deliverous/cocktails/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protocol Buffers built-in renderer.
+type ProtobufRender struct {
+	ContentType  string
+	HeaderPolicy render.HeaderPolicy
+	// If Deterministic is set to true, the maps are encoded in a deterministic order. Default is false.
+	Deterministic bool
+}
+
+func (renderer *ProtobufRender) SetContentType(value string) *ProtobufRender {
+	renderer.ContentType = value
+	return renderer
+}
+
+func (renderer *ProtobufRender) SetHeaderPolicy(value render.HeaderPolicy) *ProtobufRender {
+	renderer.HeaderPolicy = value
+	return renderer
+}
+
+func (renderer *ProtobufRender) SetDeterministic(value bool) *ProtobufRender {
+	renderer.Deterministic = value
+	return renderer
+}
+
+// NewProtobufRender creates a new Protocol Buffers render with default values.
+func NewProtobufRender() *ProtobufRender {
+	return &ProtobufRender{
+		ContentType: "application/x-protobuf",
+	}
+}
+
+// Render a Protocol Buffers response.
+func (renderer *ProtobufRender) Render(writer http.ResponseWriter, status int, message proto.Message) error {
+	result, err := proto.MarshalOptions{Deterministic: renderer.Deterministic}.Marshal(message)
+	if err != nil {
+		return encodeError(err)
+	}
+
+	return write(writer, status, renderer.ContentType, renderer.HeaderPolicy, result)
+}
+
+// Respond implements the render.Renderer interface. The value must be a proto.Message.
+func (renderer *ProtobufRender) Respond(writer http.ResponseWriter, status int, v interface{}, options render.Options) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("render: ProtobufRender cannot render a %T", v)
+	}
+	return renderer.Render(writer, status, message)
+}
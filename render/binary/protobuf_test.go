@@ -0,0 +1,44 @@
+package binary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deliverous/cocktails/render"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func Test_Protobuf_Render_ShouldRoundTrip(t *testing.T) {
+	message, _ := structpb.NewStruct(map[string]interface{}{"one": "hello", "count": 42.0, "tags": []interface{}{"a", "b"}})
+	recorder := httptest.NewRecorder()
+	err := NewProtobufRender().SetDeterministic(true).Render(recorder, http.StatusAccepted, message)
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Header().Get("Content-Type"), "application/x-protobuf")
+
+	result := &structpb.Struct{}
+	expect(t, proto.Unmarshal(recorder.Body.Bytes(), result), nil)
+	expect(t, proto.Equal(message, result), true)
+}
+
+func Test_Protobuf_Respond_ShouldOnlyAcceptMessages(t *testing.T) {
+	recorder, err := respond(NewProtobufRender(), http.StatusOK, wrapperspb.String("hello"), render.Options{})
+	expect(t, err, nil)
+	result := &wrapperspb.StringValue{}
+	expect(t, proto.Unmarshal(recorder.Body.Bytes(), result), nil)
+	expect(t, result.GetValue(), "hello")
+
+	recorder, err = respond(NewProtobufRender(), http.StatusOK, "hello", render.Options{})
+	refute(t, err, nil)
+	expect(t, recorder.Body.Len(), 0)
+}
+
+func Test_Protobuf_Render_WhenContentTypeAlreadySet_ShouldFollowTheHeaderPolicy(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewProtobufRender().SetContentType("application/protobuf").SetHeaderPolicy(render.OverrideContentType).Render(recorder, http.StatusOK, wrapperspb.Bool(true))
+	expect(t, recorder.Header().Get("Content-Type"), "application/protobuf")
+}
@@ -0,0 +1,20 @@
+package render
+
+import (
+	"net/http"
+
+	"github.com/deliverous/cocktails/internal/cspnonce"
+)
+
+// WithCSPNonce returns a copy of the request holding the Content-Security-Policy nonce of its response. The
+// templates rendered by a TemplateRender for this request get it with the cspNonce function:
+//
+//	<script nonce="{{ cspNonce }}">...</script>
+func WithCSPNonce(request *http.Request, nonce string) *http.Request {
+	return cspnonce.With(request, nonce)
+}
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, empty if none or if the request is nil.
+func CSPNonce(request *http.Request) string {
+	return cspnonce.Get(request)
+}
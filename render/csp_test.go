@@ -0,0 +1,41 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func nonceTemplate() (string, string) {
+	return "nonce", `<script nonce="{{ cspNonce }}"></script>`
+}
+
+func Test_CSPNonce_WithoutNonce_ShouldBeEmpty(t *testing.T) {
+	expect(t, CSPNonce(nil), "")
+	expect(t, CSPNonce(httptest.NewRequest("GET", "/", nil)), "")
+}
+
+func Test_TemplateRender_CSPNonce_ShouldBeTheNonceOfTheRequest(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewStaticTemplateFactory("static").SetSubTemplates(nonceTemplate))
+	for _, nonce := range []string{"first", "second"} {
+		recorder := httptest.NewRecorder()
+		request := WithCSPNonce(httptest.NewRequest("GET", "/", nil), nonce)
+		err := render.RenderRequest(recorder, request, http.StatusOK, "nonce", nil)
+		expect(t, err, nil)
+		expect(t, recorder.Body.String(), `<script nonce="`+nonce+`"></script>`)
+	}
+
+	recorder := httptest.NewRecorder()
+	err := render.RenderRequest(recorder, httptest.NewRequest("GET", "/", nil), http.StatusOK, "nonce", nil)
+	expect(t, err, nil)
+	expect(t, recorder.Body.String(), `<script nonce=""></script>`)
+}
+
+func Test_TemplateRender_CSPNonce_WithoutRequest_ShouldFail(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewStaticTemplateFactory("static").SetSubTemplates(nonceTemplate))
+	recorder, err := renderTemplate(render, http.StatusOK, "nonce", nil)
+	expectPhase(t, err, EncodePhase)
+	expect(t, strings.Contains(err.Error(), "cspNonce needs a request"), true)
+	expect(t, recorder.Body.String(), "")
+}
@@ -0,0 +1,308 @@
+package render
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSV built-in renderer.
+//
+// It renders slices of structs, one record per element, and slices of string slices. The columns of a struct
+// are its exported fields, including the fields of its embedded structs, named after their csv tag, or their
+// name when untagged. A field tagged with csv:"-" is ignored. The values are formatted with their MarshalText or
+// String method if any, with strconv otherwise, and nil pointers are empty.
+type CSVRender struct {
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+	// Delimiter is the field delimiter. Default is ','.
+	Delimiter rune
+	// HeaderRow defines if a first record containing the column names is written for structs. Default is true.
+	HeaderRow bool
+	// UseCRLF defines if the lines end with \r\n instead of \n. Default is false.
+	UseCRLF bool
+	// FlushEvery is the number of records written between two flushes by RenderIterator. Default is 1.
+	FlushEvery int
+}
+
+func (render *CSVRender) SetContentType(value string) *CSVRender {
+	render.ContentType = value
+	return render
+}
+
+func (render *CSVRender) SetCharset(value string) *CSVRender {
+	render.Charset = value
+	return render
+}
+
+func (render *CSVRender) SetHeaderPolicy(value HeaderPolicy) *CSVRender {
+	render.HeaderPolicy = value
+	return render
+}
+
+func (render *CSVRender) SetDelimiter(value rune) *CSVRender {
+	render.Delimiter = value
+	return render
+}
+
+func (render *CSVRender) SetHeaderRow(value bool) *CSVRender {
+	render.HeaderRow = value
+	return render
+}
+
+func (render *CSVRender) SetUseCRLF(value bool) *CSVRender {
+	render.UseCRLF = value
+	return render
+}
+
+func (render *CSVRender) SetFlushEvery(value int) *CSVRender {
+	render.FlushEvery = value
+	return render
+}
+
+// NewCSVRender creates a new CSV render with default values.
+func NewCSVRender() *CSVRender {
+	return &CSVRender{
+		ContentType: "text/csv",
+		Charset:     "UTF-8",
+		Delimiter:   ',',
+		HeaderRow:   true,
+		FlushEvery:  1,
+	}
+}
+
+// Render a CSV response from a slice or an array of structs, of pointers to structs, or of string slices.
+// All the records are encoded before anything is written.
+func (render *CSVRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return encodeError(fmt.Errorf("CSVRender cannot render a %T", v))
+	}
+
+	buffer := new(bytes.Buffer)
+	output := render.newWriter(buffer)
+	encoder := &csvEncoder{headerRow: render.HeaderRow}
+	if err := encoder.start(value.Type().Elem()); err != nil {
+		return encodeError(err)
+	}
+	if err := encoder.writeHeader(output); err != nil {
+		return encodeError(err)
+	}
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.write(output, value.Index(i)); err != nil {
+			return encodeError(fmt.Errorf("element %d: %v", i, err))
+		}
+	}
+	output.Flush()
+	if err := output.Error(); err != nil {
+		return encodeError(err)
+	}
+
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, 0, buffer.Bytes())
+}
+
+// RenderIterator streams the values returned by next, one record per value. The columns are defined by the
+// type of the first value. The response is flushed every FlushEvery records and at the end.
+// If the first value cannot be encoded, nothing is written.
+func (render *CSVRender) RenderIterator(writer http.ResponseWriter, status int, next Iterator) error {
+	output := newHeaderWriter(writer, status, render.ContentType, render.Charset, render.HeaderPolicy, nil)
+	buffer := new(bytes.Buffer)
+	records := render.newWriter(buffer)
+	encoder := &csvEncoder{headerRow: render.HeaderRow}
+
+	count := 0
+	for v, ok := next(); ok; v, ok = next() {
+		value := reflect.ValueOf(v)
+		if !value.IsValid() {
+			return encodeError(fmt.Errorf("element %d: nil record", count))
+		}
+		if count == 0 {
+			if err := encoder.start(value.Type()); err != nil {
+				return encodeError(err)
+			}
+			if err := encoder.writeHeader(records); err != nil {
+				return encodeError(err)
+			}
+		}
+		if err := encoder.write(records, value); err != nil {
+			return encodeError(fmt.Errorf("element %d: %v", count, err))
+		}
+		records.Flush()
+		if err := records.Error(); err != nil {
+			return encodeError(err)
+		}
+		if _, err := output.Write(buffer.Bytes()); err != nil {
+			return err
+		}
+		buffer.Reset()
+
+		count++
+		if render.FlushEvery <= 1 || count%render.FlushEvery == 0 {
+			if err := output.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return output.Flush()
+}
+
+func (render *CSVRender) newWriter(writer io.Writer) *csv.Writer {
+	output := csv.NewWriter(writer)
+	if render.Delimiter != 0 {
+		output.Comma = render.Delimiter
+	}
+	output.UseCRLF = render.UseCRLF
+	return output
+}
+
+// csvEncoder converts the values of a type to records.
+type csvEncoder struct {
+	headerRow bool
+	// strings is true for slices of strings, which are written as is.
+	strings bool
+	columns []csvColumn
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// start defines the columns from the type of the values.
+func (encoder *csvEncoder) start(t reflect.Type) error {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.String {
+		encoder.strings = true
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("CSVRender cannot render records of %s", t)
+	}
+	encoder.columns = csvColumns(t, nil)
+	return nil
+}
+
+// csvColumns returns the columns of the exported fields of the struct type t, flattening the embedded structs.
+func csvColumns(t reflect.Type, index []int) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && tag == "" {
+			columns = append(columns, csvColumns(field.Type, fieldIndex)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: fieldIndex})
+	}
+	return columns
+}
+
+func (encoder *csvEncoder) writeHeader(writer *csv.Writer) error {
+	if encoder.strings || !encoder.headerRow {
+		return nil
+	}
+	names := make([]string, len(encoder.columns))
+	for i, column := range encoder.columns {
+		names[i] = column.name
+	}
+	return writer.Write(names)
+}
+
+func (encoder *csvEncoder) write(writer *csv.Writer, value reflect.Value) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return fmt.Errorf("nil record")
+		}
+		value = value.Elem()
+	}
+	if encoder.strings {
+		if value.Kind() != reflect.Slice || value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("expected a string slice, got %s", value.Type())
+		}
+		record := make([]string, value.Len())
+		for i := range record {
+			record[i] = value.Index(i).String()
+		}
+		return writer.Write(record)
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("expected a struct, got %s", value.Type())
+	}
+
+	record := make([]string, len(encoder.columns))
+	for i, column := range encoder.columns {
+		field, err := value.FieldByIndexErr(column.index)
+		if err != nil {
+			return err
+		}
+		if record[i], err = csvFormat(field); err != nil {
+			return fmt.Errorf("%s: %v", column.name, err)
+		}
+	}
+	return writer.Write(record)
+}
+
+// csvFormat formats a field value.
+func csvFormat(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "", nil
+		}
+		if value.Kind() == reflect.Interface {
+			return csvFormat(value.Elem())
+		}
+	}
+	if value.Type().Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if value.Type().Implements(stringerType) {
+		return value.Interface().(fmt.Stringer).String(), nil
+	}
+	if value.Kind() == reflect.Ptr {
+		return csvFormat(value.Elem())
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+	default:
+		return fmt.Sprint(value.Interface()), nil
+	}
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type CSVAudit struct {
+	Created time.Time `csv:"created"`
+}
+
+type CSVUser struct {
+	Name     string   `csv:"name"`
+	Age      int      `csv:"age"`
+	Score    float64  `csv:"score"`
+	Admin    bool     `csv:"admin"`
+	Nickname *string  `csv:"nickname"`
+	Password string   `csv:"-"`
+	Level    CSVLevel `csv:"level"`
+	Comment  string
+	CSVAudit
+	internal string
+}
+
+type CSVLevel int
+
+func (level CSVLevel) String() string {
+	return [...]string{"low", "high"}[level]
+}
+
+func csvUsers() []CSVUser {
+	nickname := "bobby"
+	created := time.Date(2016, 3, 14, 15, 9, 26, 0, time.UTC)
+	return []CSVUser{
+		{Name: "Bob", Age: 42, Score: 1.5, Admin: true, Nickname: &nickname, Password: "secret", Level: 1, Comment: `say "hi", ok`, CSVAudit: CSVAudit{created}},
+		{Name: "Alice", Age: 7, Score: 10, CSVAudit: CSVAudit{created}},
+	}
+}
+
+const csvUsersOutput = "name,age,score,admin,nickname,level,Comment,created\n" +
+	"Bob,42,1.5,true,bobby,high,\"say \"\"hi\"\", ok\",2016-03-14T15:09:26Z\n" +
+	"Alice,7,10,false,,low,,2016-03-14T15:09:26Z\n"
+
+func Test_CSV_Render_ShouldWriteAHeaderRowAndARecordPerElement(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCSVRender().Render(recorder, http.StatusOK, csvUsers())
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "text/csv; charset=UTF-8")
+	expect(t, recorder.Body.String(), csvUsersOutput)
+}
+
+func Test_CSV_Render_WithPointers_ShouldWriteTheStructs(t *testing.T) {
+	users := csvUsers()
+	recorder := httptest.NewRecorder()
+	NewCSVRender().Render(recorder, http.StatusOK, []*CSVUser{&users[0], &users[1]})
+	expect(t, recorder.Body.String(), csvUsersOutput)
+}
+
+func Test_CSV_Render_WithOptions_ShouldUseThem(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewCSVRender().SetDelimiter(';').SetHeaderRow(false).SetUseCRLF(true).Render(recorder, http.StatusOK, []CSVAudit{{}})
+	expect(t, recorder.Body.String(), "0001-01-01T00:00:00Z\r\n")
+
+	recorder = httptest.NewRecorder()
+	NewCSVRender().SetDelimiter(';').Render(recorder, http.StatusOK, []struct{ A, B int }{{1, 2}})
+	expect(t, recorder.Body.String(), "A;B\n1;2\n")
+}
+
+func Test_CSV_Render_WithEmptySlice_ShouldWriteTheHeaderRow(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewCSVRender().Render(recorder, http.StatusOK, []CSVAudit{})
+	expect(t, recorder.Body.String(), "created\n")
+}
+
+func Test_CSV_Render_WithStringSlices_ShouldWriteThemAsIs(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewCSVRender().Render(recorder, http.StatusOK, [][]string{{"a", "b"}, {"c"}})
+	expect(t, recorder.Body.String(), "a,b\nc\n")
+}
+
+func Test_CSV_Render_WithUnsupportedValue_ShouldWriteNothing(t *testing.T) {
+	for _, v := range []interface{}{42, []int{1}, []*CSVAudit{nil}} {
+		recorder := httptest.NewRecorder()
+		err := NewCSVRender().Render(recorder, http.StatusOK, v)
+		expectPhase(t, err, EncodePhase)
+		expect(t, recorder.Body.String(), "")
+		expect(t, recorder.Header().Get("Content-Type"), "")
+	}
+}
+
+func Test_CSV_Render_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewCSVRender().Render(recorder, http.StatusAccepted, []CSVAudit{})
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Header().Get("Content-Type"), "bla")
+}
+
+func Test_CSV_RenderIterator_ShouldStreamTheRecords(t *testing.T) {
+	writer := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	err := NewCSVRender().SetFlushEvery(2).RenderIterator(writer, http.StatusOK, SliceIterator(csvUsers()))
+	expect(t, err, nil)
+	expect(t, writer.Body.String(), csvUsersOutput)
+	expect(t, writer.count, 2)
+}
+
+func Test_CSV_RenderIterator_WithChannel_ShouldStreamTheRecords(t *testing.T) {
+	channel := make(chan []string, 2)
+	channel <- []string{"a", "b"}
+	channel <- []string{"c"}
+	close(channel)
+	recorder := httptest.NewRecorder()
+	NewCSVRender().RenderIterator(recorder, http.StatusOK, ChannelIterator(channel))
+	expect(t, recorder.Body.String(), "a,b\nc\n")
+}
+
+func Test_CSV_RenderIterator_WhenTheFirstValueIsInvalid_ShouldWriteNothing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCSVRender().RenderIterator(recorder, http.StatusOK, SliceIterator([]int{1}))
+	expectPhase(t, err, EncodePhase)
+	expect(t, recorder.Body.String(), "")
+	expect(t, recorder.Header().Get("Content-Type"), "")
+}
+
+func Test_CSV_RenderIterator_WhenAValueIsInvalid_ShouldReturnAnError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCSVRender().RenderIterator(recorder, http.StatusOK, SliceIterator([]interface{}{CSVAudit{}, 2}))
+	expectPhase(t, err, EncodePhase)
+	expect(t, err.Error(), "render: encode: element 1: expected a struct, got int")
+	expect(t, recorder.Body.String(), "created\n0001-01-01T00:00:00Z\n")
+}
+
+func Test_CSV_RenderIterator_WithANilValue_ShouldReturnAnError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewCSVRender().RenderIterator(recorder, http.StatusOK, SliceIterator([]interface{}{nil}))
+	expectPhase(t, err, EncodePhase)
+	expect(t, err.Error(), "render: encode: element 0: nil record")
+	expect(t, recorder.Body.String(), "")
+
+	err = NewCSVRender().RenderIterator(httptest.NewRecorder(), http.StatusOK, SliceIterator([]interface{}{CSVAudit{}, nil}))
+	expect(t, err.Error(), "render: encode: element 1: nil record")
+}
+
+func Test_CSV_RenderIterator_WhenWriteFails_ShouldReturnTheError(t *testing.T) {
+	failure := errors.New("failure")
+	err := NewCSVRender().RenderIterator(newFailingWriter(0, failure), http.StatusOK, SliceIterator(csvUsers()))
+	expectPhase(t, err, BodyPhase)
+	expect(t, errors.Is(err, failure), true)
+}
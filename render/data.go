@@ -6,8 +6,9 @@ import (
 
 // Data built-in renderer.
 type DataRender struct {
-	ContentType string
-	Charset     string
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
 }
 
 func (render *DataRender) SetContentType(value string) *DataRender {
@@ -20,6 +21,11 @@ func (render *DataRender) SetCharset(value string) *DataRender {
 	return render
 }
 
+func (render *DataRender) SetHeaderPolicy(value HeaderPolicy) *DataRender {
+	render.HeaderPolicy = value
+	return render
+}
+
 // NewDataRender creates a new Data render with default values.
 func NewDataRender() *DataRender {
 	return &DataRender{
@@ -30,7 +36,6 @@ func NewDataRender() *DataRender {
 
 // Render a data response.
 func (render *DataRender) Render(writer http.ResponseWriter, status int, data []byte) error {
-	writeHeader(writer, status, render.ContentType, render.Charset)
-	writer.Write(data)
-	return nil
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, 0, data)
 }
@@ -18,6 +18,20 @@ func Test_Data_Render_WhenContentTypeAlreadySet_ShouldDoNothing(t *testing.T) {
 	expect(t, recorder.Header().Get("Content-Type"), "bla")
 }
 
+func Test_Data_Render_WhenContentTypeAlreadySet_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewDataRender().Render(recorder, http.StatusNotFound, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+}
+
+func Test_Data_Render_WhenContentTypeAlreadySetWithOverridePolicy_ShouldReplaceTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewDataRender().SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+}
+
 func Test_Data_Render_WhenNoContentType_ShouldSetTheContentType(t *testing.T) {
 	recorder, _ := renderData(NewDataRender(), http.StatusOK, nil)
 	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
@@ -38,6 +52,11 @@ func Test_Data_Render_WithEmptyCharset_ShouldUseUtf8(t *testing.T) {
 	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
 }
 
+func Test_Data_Render_WithBinaryContentType_ShouldNotSetTheCharset(t *testing.T) {
+	recorder, _ := renderData(NewDataRender().SetContentType("application/octet-stream"), http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/octet-stream")
+}
+
 func Test_Data_Render_WithData_ShouldBeOK(t *testing.T) {
 	recorder, _ := renderData(NewDataRender(), http.StatusOK, []byte("Hello world"))
 	expect(t, recorder.Body.String(), `Hello world`)
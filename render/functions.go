@@ -0,0 +1,391 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Functions returns the standard library of template functions. It is opt-in: pass it to the factory with
+// SetFunctions(render.Functions()).
+//
+// The functions taking a value and options take the value last, so that they can be used in pipelines:
+// {{ .Name | default "anonymous" | upper }}. They return plain values, which html/template escapes according
+// to the context; only the safe* functions mark their argument as trusted content, and must never be used
+// with user input.
+//
+// Strings: lower, upper, title, trim, trimPrefix, trimSuffix, contains, hasPrefix, hasSuffix, replace, split,
+// join, repeat, truncate.
+// Values: default, empty, coalesce, toJSON, safeHTML, safeHTMLAttr, safeURL, safeJS, safeCSS.
+// Math on integers: add, sub, mul, div, mod, min, max.
+// Collections: dict, list, append, first, last, keys, has, until.
+// Time: now, formatTime, parseTime, since.
+// URLs: url.
+func Functions() template.FuncMap {
+	return template.FuncMap{
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"replace":    func(old string, new string, s string) string { return strings.Replace(s, old, new, -1) },
+		"split":      func(separator string, s string) []string { return strings.Split(s, separator) },
+		"join":       join,
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"truncate":   truncate,
+
+		"default":      defaultValue,
+		"empty":        empty,
+		"coalesce":     coalesce,
+		"toJSON":       toJSON,
+		"safeHTML":     func(s string) template.HTML { return template.HTML(s) },
+		"safeHTMLAttr": func(s string) template.HTMLAttr { return template.HTMLAttr(s) },
+		"safeURL":      func(s string) template.URL { return template.URL(s) },
+		"safeJS":       func(s string) template.JS { return template.JS(s) },
+		"safeCSS":      func(s string) template.CSS { return template.CSS(s) },
+
+		"add": arithmetic(func(a, b int64) (int64, error) { return a + b, nil }),
+		"sub": arithmetic(func(a, b int64) (int64, error) { return a - b, nil }),
+		"mul": arithmetic(func(a, b int64) (int64, error) { return a * b, nil }),
+		"div": arithmetic(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errDivisionByZero
+			}
+			return a / b, nil
+		}),
+		"mod": arithmetic(func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errDivisionByZero
+			}
+			return a % b, nil
+		}),
+		"min": arithmetic(func(a, b int64) (int64, error) {
+			if b < a {
+				return b, nil
+			}
+			return a, nil
+		}),
+		"max": arithmetic(func(a, b int64) (int64, error) {
+			if b > a {
+				return b, nil
+			}
+			return a, nil
+		}),
+
+		"dict":   dict,
+		"list":   func(values ...interface{}) []interface{} { return values },
+		"append": appendValue,
+		"first":  first,
+		"last":   last,
+		"keys":   keys,
+		"has":    has,
+		"until":  until,
+
+		"now":        time.Now,
+		"formatTime": func(layout string, t time.Time) string { return t.Format(layout) },
+		"parseTime":  time.Parse,
+		"since":      func(t time.Time) time.Duration { return time.Since(t).Round(time.Second) },
+
+		"url": buildURL,
+	}
+}
+
+var errDivisionByZero = errors.New("render: division by zero")
+
+// title upper cases the first letter of each word.
+func title(s string) string {
+	previous := ' '
+	return strings.Map(func(r rune) rune {
+		word := unicode.IsSpace(previous) || unicode.IsPunct(previous)
+		previous = r
+		if word {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// join concatenates the elements of a slice, formatted with fmt, separated by separator.
+func join(separator string, values interface{}) (string, error) {
+	value := reflect.ValueOf(values)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return "", fmt.Errorf("render: join expects a slice, got %T", values)
+	}
+	parts := make([]string, value.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(value.Index(i).Interface())
+	}
+	return strings.Join(parts, separator), nil
+}
+
+// truncate shortens s to length characters, the last one being an ellipsis when s is truncated.
+func truncate(length int, s string) string {
+	if length <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:length-1]) + "…"
+}
+
+// defaultValue returns value, or fallback if value is empty.
+func defaultValue(fallback interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || empty(value[0]) {
+		return fallback
+	}
+	return value[0]
+}
+
+// empty returns true for nil, false, zero numbers, empty strings, slices and maps, and nil pointers.
+func empty(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String, reflect.Chan:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface, reflect.Func:
+		return v.IsNil()
+	case reflect.Struct:
+		if t, ok := value.(time.Time); ok {
+			return t.IsZero()
+		}
+		return false
+	default:
+		return v.IsZero()
+	}
+}
+
+// coalesce returns the first non-empty value, or nil.
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !empty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// toJSON encodes the value in JSON. The result is trusted in JavaScript contexts, like inside a <script>
+// element, as the encoding escapes <, >, & and the line terminators; elsewhere it is escaped as any other string.
+func toJSON(value interface{}) (template.JS, error) {
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(result), nil
+}
+
+// arithmetic adapts an operation on int64 to accept any integer type.
+func arithmetic(operation func(a, b int64) (int64, error)) func(a, b interface{}) (int64, error) {
+	return func(a, b interface{}) (int64, error) {
+		x, err := toInt64(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toInt64(b)
+		if err != nil {
+			return 0, err
+		}
+		return operation(x, y)
+	}
+}
+
+func toInt64(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint()), nil
+	default:
+		return 0, fmt.Errorf("render: expected an integer, got %T", value)
+	}
+}
+
+// dict creates a map from a list of key and value pairs, typically to pass several values to a template:
+// {{ template "card" dict "Title" .Title "User" .User }}.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("render: dict expects pairs of key and value")
+	}
+	result := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("render: dict keys must be strings, got %T", pairs[i])
+		}
+		result[key] = pairs[i+1]
+	}
+	return result, nil
+}
+
+// appendValue returns a new slice made of the elements of values followed by value.
+func appendValue(value interface{}, values interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(values)
+	if values != nil && v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("render: append expects a slice, got %T", values)
+	}
+	result := make([]interface{}, 0, v.Len()+1)
+	for i := 0; values != nil && i < v.Len(); i++ {
+		result = append(result, v.Index(i).Interface())
+	}
+	return append(result, value), nil
+}
+
+// first returns the first element of a slice or an array, or the first character of a string, nil if empty.
+func first(values interface{}) (interface{}, error) {
+	v, err := sequence("first", values)
+	if err != nil || v.Len() == 0 {
+		return nil, err
+	}
+	if v.Kind() == reflect.String {
+		r, _ := utf8.DecodeRuneInString(v.String())
+		return string(r), nil
+	}
+	return v.Index(0).Interface(), nil
+}
+
+// last returns the last element of a slice or an array, or the last character of a string, nil if empty.
+func last(values interface{}) (interface{}, error) {
+	v, err := sequence("last", values)
+	if err != nil || v.Len() == 0 {
+		return nil, err
+	}
+	if v.Kind() == reflect.String {
+		r, _ := utf8.DecodeLastRuneInString(v.String())
+		return string(r), nil
+	}
+	return v.Index(v.Len() - 1).Interface(), nil
+}
+
+func sequence(function string, values interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(values)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String:
+		return v, nil
+	default:
+		return v, fmt.Errorf("render: %s expects a slice or a string, got %T", function, values)
+	}
+}
+
+// keys returns the sorted keys of a map with string keys.
+func keys(values interface{}) ([]string, error) {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("render: keys expects a map with string keys, got %T", values)
+	}
+	result := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		result = append(result, key.String())
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// has returns true if the slice contains the value, or if the map contains the key.
+func has(collection interface{}, value interface{}) (bool, error) {
+	v := reflect.ValueOf(collection)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if reflect.DeepEqual(v.Index(i).Interface(), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		key := reflect.ValueOf(value)
+		if !key.IsValid() || !key.Type().AssignableTo(v.Type().Key()) {
+			return false, nil
+		}
+		return v.MapIndex(key).IsValid(), nil
+	default:
+		return false, fmt.Errorf("render: has expects a slice or a map, got %T", collection)
+	}
+}
+
+// until returns the integers from 0 to count excluded, to repeat a part of a template: {{ range until 3 }}.
+func until(count int) []int {
+	if count < 0 {
+		count = 0
+	}
+	result := make([]int, count)
+	for i := range result {
+		result[i] = i
+	}
+	return result
+}
+
+// buildURL adds the query parameters, given as pairs of key and value, to the path:
+// {{ url "/search" "q" .Query "page" 2 }}.
+func buildURL(path string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", errors.New("render: url expects pairs of key and value")
+	}
+	parsed, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return "", fmt.Errorf("render: url keys must be strings, got %T", pairs[i])
+		}
+		query.Add(key, fmt.Sprint(pairs[i+1]))
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// AssetResolver returns the public URL of an asset, usually including a fingerprint of its content so that
+// it can be cached forever.
+type AssetResolver func(name string) (string, error)
+
+// AssetFunctions returns the asset function, which resolves the URL of an asset with resolver:
+// <link rel="stylesheet" href="{{ asset "css/site.css" }}">.
+func AssetFunctions(resolver AssetResolver) template.FuncMap {
+	return template.FuncMap{
+		"asset": resolver,
+	}
+}
+
+// FingerprintAssets creates an AssetResolver for the files of fileSystem: the URL is the prefix followed by
+// the name of the file, with a hash of its content in the v query parameter, like /static/css/site.css?v=62368a1a.
+// The hashes are computed once per file.
+func FingerprintAssets(fileSystem fs.FS, prefix string) AssetResolver {
+	var hashes sync.Map
+	return func(name string) (string, error) {
+		name = strings.TrimPrefix(name, "/")
+		hash, ok := hashes.Load(name)
+		if !ok {
+			content, err := fs.ReadFile(fileSystem, name)
+			if err != nil {
+				return "", err
+			}
+			sum := sha256.Sum256(content)
+			hash, _ = hashes.LoadOrStore(name, hex.EncodeToString(sum[:4]))
+		}
+		return prefix + name + "?v=" + hash.(string), nil
+	}
+}
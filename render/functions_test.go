@@ -0,0 +1,109 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func executeFunctions(t *testing.T, text string, binding interface{}) string {
+	tmpl, err := template.New("test").Funcs(Functions()).Funcs(AssetFunctions(FingerprintAssets(assetsFS, "/static/"))).Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := tmpl.Execute(out, binding); err != nil {
+		return "error: " + err.Error()
+	}
+	return out.String()
+}
+
+var assetsFS = fstest.MapFS{"css/site.css": {Data: []byte("body {}")}}
+
+func Test_Functions_Strings(t *testing.T) {
+	expect(t, executeFunctions(t, `{{ "Hello" | upper }} {{ "Hello" | lower }}`, nil), "HELLO hello")
+	expect(t, executeFunctions(t, `{{ "hello big-world" | title }}`, nil), "Hello Big-World")
+	expect(t, executeFunctions(t, `[{{ " a " | trim }}]`, nil), "[a]")
+	expect(t, executeFunctions(t, `{{ "file.txt" | trimSuffix ".txt" | trimPrefix "fi" }}`, nil), "le")
+	expect(t, executeFunctions(t, `{{ "abc" | contains "b" }} {{ "abc" | hasPrefix "b" }} {{ "abc" | hasSuffix "c" }}`, nil), "true false true")
+	expect(t, executeFunctions(t, `{{ "a-b-c" | replace "-" "+" }}`, nil), "a&#43;b&#43;c")
+	expect(t, executeFunctions(t, `{{ "a,b,c" | split "," | join " " }}`, nil), "a b c")
+	expect(t, executeFunctions(t, `{{ .Numbers | join ", " }}`, map[string]interface{}{"Numbers": []int{1, 2}}), "1, 2")
+	expect(t, executeFunctions(t, `{{ "ab" | repeat 3 }}`, nil), "ababab")
+	expect(t, executeFunctions(t, `{{ "héllo world" | truncate 5 }}|{{ "short" | truncate 5 }}`, nil), "héll…|short")
+}
+
+func Test_Functions_ShouldRespectTheEscaping(t *testing.T) {
+	expect(t, executeFunctions(t, `{{ "<b>" | upper }}`, nil), "&lt;B&gt;")
+	expect(t, executeFunctions(t, `{{ .Missing | default "<i>" }}`, map[string]interface{}{}), "&lt;i&gt;")
+	expect(t, executeFunctions(t, `{{ "<b>x</b>" | safeHTML }}`, nil), "<b>x</b>")
+	expect(t, executeFunctions(t, `<a href="{{ "javascript:alert(1)" }}">`, nil), `<a href="#ZgotmplZ">`)
+	expect(t, executeFunctions(t, `<a href="{{ "javascript:void" | safeURL }}">`, nil), `<a href="javascript:void">`)
+}
+
+func Test_Functions_ToJSON(t *testing.T) {
+	binding := map[string]interface{}{"Data": map[string]string{"html": "</script><b>"}}
+	expect(t, executeFunctions(t, `<script>var data = {{ toJSON .Data }};</script>`, binding),
+		`<script>var data = {"html":"\u003c/script\u003e\u003cb\u003e"};</script>`)
+	expect(t, executeFunctions(t, `<p>{{ toJSON .Data }}</p>`, binding),
+		`<p>{&#34;html&#34;:&#34;\u003c/script\u003e\u003cb\u003e&#34;}</p>`)
+	expect(t, strings.HasPrefix(executeFunctions(t, `{{ toJSON .Data }}`, map[string]interface{}{"Data": make(chan int)}), "error: "), true)
+}
+
+func Test_Functions_Values(t *testing.T) {
+	binding := map[string]interface{}{"Zero": 0, "Name": "bob", "Empty": []string{}, "Nil": (*int)(nil)}
+	expect(t, executeFunctions(t, `{{ .Zero | default 42 }} {{ .Name | default "anonymous" }}`, binding), "42 bob")
+	expect(t, executeFunctions(t, `{{ empty .Zero }} {{ empty .Name }} {{ empty .Empty }} {{ empty .Nil }} {{ empty .Missing }}`, binding), "true false true true true")
+	expect(t, executeFunctions(t, `{{ coalesce .Missing .Zero .Name }}`, binding), "bob")
+}
+
+func Test_Functions_Math(t *testing.T) {
+	expect(t, executeFunctions(t, `{{ add 1 2 }} {{ sub 1 2 }} {{ mul 3 4 }} {{ div 7 2 }} {{ mod 7 2 }} {{ min 3 4 }} {{ max 3 4 }}`, nil), "3 -1 12 3 1 3 4")
+	expect(t, executeFunctions(t, `{{ add .Count 1 }}`, map[string]interface{}{"Count": uint8(4)}), "5")
+	expect(t, executeFunctions(t, `{{ len .Items | sub 1 }}`, map[string]interface{}{"Items": []int{1, 2, 3}}), "-2")
+	expect(t, strings.Contains(executeFunctions(t, `{{ div 1 0 }}`, nil), "division by zero"), true)
+	expect(t, strings.Contains(executeFunctions(t, `{{ add 1 "2" }}`, nil), "expected an integer, got string"), true)
+}
+
+func Test_Functions_Collections(t *testing.T) {
+	binding := map[string]interface{}{"Items": []string{"a", "b", "c"}, "Map": map[string]int{"z": 1, "y": 2}}
+	expect(t, executeFunctions(t, `{{ $d := dict "Name" "bob" "Age" 42 }}{{ $d.Name }} {{ $d.Age }}`, nil), "bob 42")
+	expect(t, strings.Contains(executeFunctions(t, `{{ dict "Name" }}`, nil), "pairs"), true)
+	expect(t, strings.Contains(executeFunctions(t, `{{ dict 1 2 }}`, nil), "keys must be strings"), true)
+	expect(t, executeFunctions(t, `{{ range list 1 "two" 3 }}[{{ . }}]{{ end }}`, nil), "[1][two][3]")
+	expect(t, executeFunctions(t, `{{ .Items | append "d" | join "" }}`, binding), "abcd")
+	expect(t, executeFunctions(t, `{{ first .Items }} {{ last .Items }} {{ first .Empty }}`, map[string]interface{}{"Items": []int{1, 2}, "Empty": []int{}}), "1 2 ")
+	expect(t, executeFunctions(t, `{{ first "abc" }} {{ last "abc" }} {{ first "été" }}{{ last "café" }} [{{ first "" }}]`, nil), "a c éé []")
+	expect(t, executeFunctions(t, `{{ keys .Map | join "," }}`, binding), "y,z")
+	expect(t, executeFunctions(t, `{{ has .Items "b" }} {{ has .Items "d" }} {{ has .Map "z" }} {{ has .Map 1 }}`, binding), "true false true false")
+	expect(t, executeFunctions(t, `{{ range until 3 }}{{ . }}{{ end }}`, nil), "012")
+}
+
+func Test_Functions_Time(t *testing.T) {
+	binding := map[string]interface{}{"Date": time.Date(2016, 3, 14, 15, 9, 26, 0, time.UTC)}
+	expect(t, executeFunctions(t, `{{ .Date | formatTime "2006-01-02 15:04" }}`, binding), "2016-03-14 15:09")
+	expect(t, executeFunctions(t, `{{ (parseTime "2006-01-02" "2016-03-14").Year }}`, nil), "2016")
+	expect(t, executeFunctions(t, `{{ (now).IsZero }}`, nil), "false")
+	expect(t, executeFunctions(t, `{{ since .Date | printf "%T" }}`, binding), "time.Duration")
+}
+
+func Test_Functions_URL(t *testing.T) {
+	expect(t, executeFunctions(t, `<a href="{{ url "/search" "q" .Query "page" 2 }}">`, map[string]interface{}{"Query": "a&b <c>"}),
+		`<a href="/search?page=2&amp;q=a%26b&#43;%3Cc%3E">`)
+	expect(t, executeFunctions(t, `{{ url "/search?lang=fr" "q" "x" }}`, nil), "/search?lang=fr&amp;q=x")
+}
+
+func Test_Functions_Asset_ShouldFingerprintTheFile(t *testing.T) {
+	expect(t, executeFunctions(t, `<link href="{{ asset "css/site.css" }}">`, nil), `<link href="/static/css/site.css?v=62368a1a">`)
+	expect(t, strings.HasPrefix(executeFunctions(t, `{{ asset "missing.css" }}`, nil), "error: "), true)
+}
+
+func Test_Functions_ShouldBeUsableByTheFactories(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewStaticTemplateFactory("test").SetFunctions(Functions()).SetSubTemplates(
+		func() (string, string) { return "main", `{{ .Name | default "World" | upper }}` }))
+	recorder, _ := renderTemplate(render, 200, "main", map[string]interface{}{})
+	expect(t, recorder.Body.String(), "WORLD")
+}
@@ -12,6 +12,6 @@ func expect(t *testing.T, value interface{}, expexted interface{}) {
 
 func refute(t *testing.T, value interface{}, expexted interface{}) {
 	if value == expexted {
-		t.Errorf("%#v not expected.", expexted, value)
+		t.Errorf("%#v not expected.", value)
 	}
 }
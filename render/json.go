@@ -6,10 +6,17 @@ import (
 )
 
 type JSONRender struct {
-	ContentType string
-	Charset     string
-	Indent      bool
-	Prefix      []byte
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+	Indent       bool
+	Prefix       []byte
+	// If Stream is set to true, the value is encoded directly on the response instead of being marshalled first.
+	// The encoder still builds the whole encoding of the value in memory before writing it: only RenderArray and
+	// RenderLines write the elements as they come. Default is false.
+	Stream bool
+	// FlushEvery is the number of elements written between two flushes by RenderArray and RenderLines. Default is 1.
+	FlushEvery int
 }
 
 func (render *JSONRender) SetContentType(value string) *JSONRender {
@@ -22,6 +29,11 @@ func (render *JSONRender) SetCharset(value string) *JSONRender {
 	return render
 }
 
+func (render *JSONRender) SetHeaderPolicy(value HeaderPolicy) *JSONRender {
+	render.HeaderPolicy = value
+	return render
+}
+
 func (render *JSONRender) SetIndent(value bool) *JSONRender {
 	render.Indent = value
 	return render
@@ -32,33 +44,64 @@ func (render *JSONRender) SetPrefix(prefix []byte) *JSONRender {
 	return render
 }
 
+func (render *JSONRender) SetStream(value bool) *JSONRender {
+	render.Stream = value
+	return render
+}
+
+func (render *JSONRender) SetFlushEvery(value int) *JSONRender {
+	render.FlushEvery = value
+	return render
+}
+
 // NewJSONRender creates a new JSON render with default values.
 func NewJSONRender() *JSONRender {
 	return &JSONRender{
 		ContentType: "application/json",
 		Charset:     "UTF-8",
 		Indent:      false,
+		FlushEvery:  1,
 	}
 }
 
 // Render a JSON response.
 func (render *JSONRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	if render.Stream {
+		return render.stream(writer, status, v)
+	}
+
 	result, err := marshallToJson(v, render.Indent)
 	if err != nil {
-		return err
+		return encodeError(err)
 	}
 
-	writeHeader(writer, status, render.ContentType, render.Charset)
-	if len(render.Prefix) > 0 {
-		writer.Write(render.Prefix)
-	}
-	writer.Write(result)
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, len(render.Prefix), concat(render.Prefix, result, render.Indent))
+}
+
+// stream encodes the value directly on the response. The encoder always terminates the value with a newline.
+func (render *JSONRender) stream(writer http.ResponseWriter, status int, v interface{}) error {
+	encoder := json.NewEncoder(newHeaderWriter(writer, status, render.ContentType, render.Charset, render.HeaderPolicy, render.Prefix))
 	if render.Indent {
-		writer.Write([]byte("\n"))
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return encodeError(err)
 	}
 	return nil
 }
 
+// concat builds the whole response in a single buffer: the prefix, the payload and an ending newline if needed.
+func concat(prefix []byte, payload []byte, newline bool) []byte {
+	result := make([]byte, 0, len(prefix)+len(payload)+1)
+	result = append(result, prefix...)
+	result = append(result, payload...)
+	if newline {
+		result = append(result, '\n')
+	}
+	return result
+}
+
 func marshallToJson(v interface{}, indent bool) ([]byte, error) {
 	if indent {
 		return json.MarshalIndent(v, "", "  ")
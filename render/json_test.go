@@ -24,6 +24,20 @@ func Test_JSON_Render_WhenContentTypeAlreadySet_ShouldDoNothing(t *testing.T) {
 	expect(t, recorder.Header().Get("Content-Type"), "bla")
 }
 
+func Test_JSON_Render_WhenContentTypeAlreadySet_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewJSONRender().Render(recorder, http.StatusNotFound, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+}
+
+func Test_JSON_Render_WhenContentTypeAlreadySetWithOverridePolicy_ShouldReplaceTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewJSONRender().SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+}
+
 func Test_JSON_Render_WhenNoContentType_ShouldSetTheContentType(t *testing.T) {
 	recorder, _ := renderJSON(NewJSONRender(), http.StatusOK, nil)
 	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
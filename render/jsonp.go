@@ -1,13 +1,30 @@
 package render
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
+	"strings"
 )
 
+// ErrInvalidCallback is returned when a JSONP callback is not a valid JavaScript identifier or dotted path.
+var ErrInvalidCallback = errors.New("render: invalid JSONP callback")
+
 type JSONPRender struct {
-	ContentType string
-	Charset     string
-	Indent      bool
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+	Indent       bool
+	// MaxCallbackLength is the maximum length of the callback. Default is 128.
+	MaxCallbackLength int
+	// If CommentPrefix is set to true, the response starts with an empty comment "/**/" to prevent
+	// content sniffing attacks like Rosetta Flash. Default is true.
+	CommentPrefix bool
+	// CallbackParameter is the query parameter read by RenderRequest. Default is "callback".
+	CallbackParameter string
+	// Fallback renders the value as plain JSON when the request has no callback. Default, or when nil, is
+	// NewJSONRender().
+	Fallback *JSONRender
 }
 
 func (render *JSONPRender) SetContentType(value string) *JSONPRender {
@@ -20,33 +37,125 @@ func (render *JSONPRender) SetCharset(value string) *JSONPRender {
 	return render
 }
 
+func (render *JSONPRender) SetHeaderPolicy(value HeaderPolicy) *JSONPRender {
+	render.HeaderPolicy = value
+	return render
+}
+
 func (render *JSONPRender) SetIndent(value bool) *JSONPRender {
 	render.Indent = value
 	return render
 }
 
+func (render *JSONPRender) SetMaxCallbackLength(value int) *JSONPRender {
+	render.MaxCallbackLength = value
+	return render
+}
+
+func (render *JSONPRender) SetCommentPrefix(value bool) *JSONPRender {
+	render.CommentPrefix = value
+	return render
+}
+
+func (render *JSONPRender) SetCallbackParameter(value string) *JSONPRender {
+	render.CallbackParameter = value
+	return render
+}
+
+func (render *JSONPRender) SetFallback(value *JSONRender) *JSONPRender {
+	render.Fallback = value
+	return render
+}
+
 // NewJSONPRender creates a new JSONP render with default values.
 func NewJSONPRender() *JSONPRender {
 	return &JSONPRender{
-		ContentType: "application/javascript",
-		Charset:     "UTF-8",
-		Indent:      false,
+		ContentType:       "application/javascript",
+		Charset:           "UTF-8",
+		Indent:            false,
+		MaxCallbackLength: 128,
+		CommentPrefix:     true,
+		CallbackParameter: "callback",
+		Fallback:          NewJSONRender(),
 	}
 }
 
 // Render a JSONP response.
+// The callback is validated with ValidateCallback and ErrInvalidCallback is returned, without writing anything, when invalid.
 func (render *JSONPRender) Render(writer http.ResponseWriter, status int, callback string, v interface{}) error {
+	if err := ValidateCallback(callback, render.MaxCallbackLength); err != nil {
+		return err
+	}
+
 	result, err := marshallToJson(v, render.Indent)
 	if err != nil {
-		return err
+		return encodeError(err)
 	}
 
-	writeHeader(writer, status, render.ContentType, render.Charset)
-	writer.Write([]byte(callback + "("))
-	writer.Write(result)
-	writer.Write([]byte(");"))
-	if render.Indent {
-		writer.Write([]byte("\n"))
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	prefix := callback + "("
+	if render.CommentPrefix {
+		prefix = "/**/" + prefix
+	}
+	return write(writer, len(prefix), concat([]byte(prefix), append(escapeLineTerminators(result), ");"...), render.Indent))
+}
+
+// RenderRequest renders a JSONP response using the callback given by the CallbackParameter query parameter.
+// When the parameter is absent or empty, the value is rendered as plain JSON with the Fallback renderer.
+func (render *JSONPRender) RenderRequest(writer http.ResponseWriter, request *http.Request, status int, v interface{}) error {
+	callback := request.URL.Query().Get(render.CallbackParameter)
+	if callback == "" {
+		fallback := render.Fallback
+		if fallback == nil {
+			fallback = NewJSONRender()
+		}
+		return fallback.Render(writer, status, v)
+	}
+	return render.Render(writer, status, callback, v)
+}
+
+// ValidateCallback checks that the callback is a JavaScript identifier or a dotted path of identifiers, like
+// "jQuery123" or "app.handlers.done", no longer than maxLength (when positive), and without reserved words.
+func ValidateCallback(callback string, maxLength int) error {
+	if callback == "" || (maxLength > 0 && len(callback) > maxLength) {
+		return ErrInvalidCallback
+	}
+	for _, identifier := range strings.Split(callback, ".") {
+		if !isIdentifier(identifier) || reservedWords[identifier] {
+			return ErrInvalidCallback
+		}
 	}
 	return nil
 }
+
+func isIdentifier(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i, c := range value {
+		switch {
+		case c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var reservedWords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true, "export": true,
+	"extends": true, "false": true, "finally": true, "for": true, "function": true, "if": true, "implements": true,
+	"import": true, "in": true, "instanceof": true, "interface": true, "let": true, "new": true, "null": true,
+	"package": true, "private": true, "protected": true, "public": true, "return": true, "static": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true, "try": true, "typeof": true,
+	"var": true, "void": true, "while": true, "with": true, "yield": true,
+}
+
+// escapeLineTerminators replaces U+2028 and U+2029, valid in JSON but not in JavaScript strings before ES2019.
+func escapeLineTerminators(data []byte) []byte {
+	data = bytes.Replace(data, []byte("\u2028"), []byte(`\u2028`), -1)
+	return bytes.Replace(data, []byte("\u2029"), []byte(`\u2029`), -1)
+}
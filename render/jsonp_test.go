@@ -1,9 +1,11 @@
 package render
 
 import (
+	"encoding/json"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -24,6 +26,20 @@ func Test_JSONP_Render_WhenContentTypeAlreadySet_ShouldDoNothing(t *testing.T) {
 	expect(t, recorder.Header().Get("Content-Type"), "bla")
 }
 
+func Test_JSONP_Render_WhenContentTypeAlreadySet_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewJSONPRender().Render(recorder, http.StatusNotFound, "callback", nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+}
+
+func Test_JSONP_Render_WhenContentTypeAlreadySetWithOverridePolicy_ShouldReplaceTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewJSONPRender().SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, "callback", nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/javascript; charset=UTF-8")
+}
+
 func Test_JSONP_Render_WhenNoContentType_ShouldSetTheContentType(t *testing.T) {
 	recorder, _ := renderJSONP(NewJSONPRender(), http.StatusOK, nil)
 	expect(t, recorder.Header().Get("Content-Type"), "application/javascript; charset=UTF-8")
@@ -46,17 +62,17 @@ func Test_JSONP_Render_WithEmptyCharset_ShouldUseUtf8(t *testing.T) {
 
 func Test_JSONP_Render_WithNilData_ShouldRenderNull(t *testing.T) {
 	recorder, _ := renderJSONP(NewJSONPRender(), http.StatusOK, nil)
-	expect(t, recorder.Body.String(), `callback(null);`)
+	expect(t, recorder.Body.String(), `/**/callback(null);`)
 }
 
 func Test_JSONP_Render_WithStruct_ShouldBeOK(t *testing.T) {
 	recorder, _ := renderJSONP(NewJSONPRender(), http.StatusOK, JSONPGreeting{"hello", "world"})
-	expect(t, recorder.Body.String(), `callback({"one":"hello","two":"world"});`)
+	expect(t, recorder.Body.String(), `/**/callback({"one":"hello","two":"world"});`)
 }
 
 func Test_JSONP_Render_WithIndent_ShouldBeOK(t *testing.T) {
 	recorder, _ := renderJSONP(NewJSONPRender().SetIndent(true), http.StatusOK, JSONPGreeting{"hello", "world"})
-	expect(t, recorder.Body.String(), "callback({\n  \"one\": \"hello\",\n  \"two\": \"world\"\n});\n")
+	expect(t, recorder.Body.String(), "/**/callback({\n  \"one\": \"hello\",\n  \"two\": \"world\"\n});\n")
 }
 
 func Test_JSONP_Render_WithInvalidData_ShouldFailWithoutWrittingAnyResponse(t *testing.T) {
@@ -66,6 +82,103 @@ func Test_JSONP_Render_WithInvalidData_ShouldFailWithoutWrittingAnyResponse(t *t
 	refute(t, err, nil)
 }
 
+func Test_JSONP_Render_ShouldSetNoSniff(t *testing.T) {
+	recorder, _ := renderJSONP(NewJSONPRender(), http.StatusOK, nil)
+	expect(t, recorder.Header().Get("X-Content-Type-Options"), "nosniff")
+}
+
+func Test_JSONP_Render_WithoutCommentPrefix_ShouldOnlyWriteTheCallback(t *testing.T) {
+	recorder, _ := renderJSONP(NewJSONPRender().SetCommentPrefix(false), http.StatusOK, nil)
+	expect(t, recorder.Body.String(), `callback(null);`)
+}
+
+func Test_JSONP_Render_WithLineTerminators_ShouldEscapeThem(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewJSONPRender().Render(recorder, http.StatusOK, "callback", json.RawMessage("\"a\u2028b\u2029c\""))
+	expect(t, recorder.Body.String(), `/**/callback("a\u2028b\u2029c");`)
+}
+
+func Test_JSONP_Render_WithInvalidCallback_ShouldFailWithoutWrittingAnyResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewJSONPRender().Render(recorder, http.StatusCreated, "alert(1);cb", nil)
+	expect(t, err, ErrInvalidCallback)
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_JSONP_ValidateCallback_ShouldAcceptIdentifiersAndDottedPaths(t *testing.T) {
+	expect(t, ValidateCallback("callback", 0), nil)
+	expect(t, ValidateCallback("$", 0), nil)
+	expect(t, ValidateCallback("_private", 0), nil)
+	expect(t, ValidateCallback("jQuery1102_1399", 0), nil)
+	expect(t, ValidateCallback("app.handlers.done", 0), nil)
+	expect(t, ValidateCallback("$.fn.plugin", 0), nil)
+}
+
+func Test_JSONP_ValidateCallback_ShouldRejectInvalidCallbacks(t *testing.T) {
+	expect(t, ValidateCallback("", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("1callback", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("call back", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("callback()", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("a[0]", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("a..b", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback(".a", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("a.", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("<script>", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("caf\u00e9", 0), ErrInvalidCallback)
+}
+
+func Test_JSONP_ValidateCallback_ShouldRejectReservedWords(t *testing.T) {
+	expect(t, ValidateCallback("function", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("app.delete", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("this.callback", 0), ErrInvalidCallback)
+	expect(t, ValidateCallback("functions", 0), nil)
+}
+
+func Test_JSONP_ValidateCallback_ShouldRejectTooLongCallbacks(t *testing.T) {
+	expect(t, ValidateCallback("abcdef", 6), nil)
+	expect(t, ValidateCallback("abcdefg", 6), ErrInvalidCallback)
+	expect(t, ValidateCallback(strings.Repeat("a", 129), NewJSONPRender().MaxCallbackLength), ErrInvalidCallback)
+}
+
+func Test_JSONP_RenderRequest_WithCallbackParameter_ShouldRenderJSONP(t *testing.T) {
+	recorder := renderJSONPRequest(NewJSONPRender(), "/?callback=done", JSONPGreeting{"hello", "world"})
+	expect(t, recorder.Header().Get("Content-Type"), "application/javascript; charset=UTF-8")
+	expect(t, recorder.Body.String(), `/**/done({"one":"hello","two":"world"});`)
+}
+
+func Test_JSONP_RenderRequest_WithSpecificCallbackParameter_ShouldRenderJSONP(t *testing.T) {
+	recorder := renderJSONPRequest(NewJSONPRender().SetCallbackParameter("jsonp"), "/?callback=ignored&jsonp=done", 1)
+	expect(t, recorder.Body.String(), `/**/done(1);`)
+}
+
+func Test_JSONP_RenderRequest_WithoutCallbackParameter_ShouldRenderJSON(t *testing.T) {
+	recorder := renderJSONPRequest(NewJSONPRender(), "/", JSONPGreeting{"hello", "world"})
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"one":"hello","two":"world"}`)
+}
+
+func Test_JSONP_RenderRequest_WithoutFallback_ShouldRenderJSON(t *testing.T) {
+	recorder := renderJSONPRequest(&JSONPRender{}, "/", JSONPGreeting{"hello", "world"})
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"one":"hello","two":"world"}`)
+}
+
+func Test_JSONP_RenderRequest_WithInvalidCallbackParameter_ShouldFail(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/?callback=alert(document.cookie)", nil)
+	err := NewJSONPRender().RenderRequest(recorder, request, http.StatusOK, 1)
+	expect(t, err, ErrInvalidCallback)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func renderJSONPRequest(render *JSONPRender, url string, v interface{}) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", url, nil)
+	render.RenderRequest(recorder, request, http.StatusOK, v)
+	return recorder
+}
+
 func renderJSONP(render *JSONPRender, status int, v interface{}) (*httptest.ResponseRecorder, error) {
 	recorder := httptest.NewRecorder()
 	err := render.Render(recorder, status, "callback", v)
@@ -0,0 +1,238 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// scopeSeparator separates the name of a template file from the name of a template defined inside it.
+const scopeSeparator = "::"
+
+// scopedName is the name under which a template defined inside the file name is also registered,
+// so that a page can redefine the blocks of its layout without conflicting with the other pages.
+// The blocks, templates defined and called by the same file, are also registered under the scope of the empty file
+// name: they are the default definitions used by the pages that do not redefine them.
+func scopedName(name string, defined string) string {
+	return name + scopeSeparator + defined
+}
+
+// layoutFunctions returns placeholders for the functions bound by TemplateRender at execution time.
+// They must be known when the templates are parsed.
+func layoutFunctions() template.FuncMap {
+	unbound := func(name string) error {
+		return fmt.Errorf("render: %s can only be called from a template executed by a TemplateRender", name)
+	}
+	return template.FuncMap{
+		"yield":    func() (template.HTML, error) { return "", unbound("yield") },
+		"partial":  func(string) (template.HTML, error) { return "", unbound("partial") },
+		"current":  func() (string, error) { return "", unbound("current") },
+		"cspNonce": func() (string, error) { return "", unbound("cspNonce") },
+	}
+}
+
+// createTemplates creates the root template of a set with the given options.
+func createTemplates(name string, options TemplateOptions) *template.Template {
+	result := template.New(name)
+	result.Delims(options.LeftDelimiter, options.RightDelimiter)
+	result.Funcs(layoutFunctions())
+	for _, funcs := range options.Functions {
+		result.Funcs(funcs)
+	}
+	return result
+}
+
+// parseTemplate parses content as the template name of the set result.
+// The templates defined inside content are registered under their own name, and also under a name scoped
+// to the file, which allows each page to redefine the blocks of its layout.
+func parseTemplate(result *template.Template, name string, content string, options TemplateOptions) error {
+	scratch := createTemplates(name, options)
+	if _, err := scratch.Parse(content); err != nil {
+		return err
+	}
+	calls := make(map[string]bool)
+	for _, tmpl := range scratch.Templates() {
+		if tmpl.Tree != nil {
+			templateCalls(tmpl.Tree.Root, calls)
+		}
+	}
+	for _, tmpl := range scratch.Templates() {
+		if tmpl.Tree == nil {
+			continue
+		}
+		if tmpl.Name() == name {
+			if _, err := result.AddParseTree(name, tmpl.Tree); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := result.AddParseTree(tmpl.Name(), tmpl.Tree); err != nil {
+			return err
+		}
+		if _, err := result.AddParseTree(scopedName(name, tmpl.Name()), tmpl.Tree); err != nil {
+			return err
+		}
+		if calls[tmpl.Name()] {
+			if _, err := result.AddParseTree(scopedName("", tmpl.Name()), tmpl.Tree); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// templateCalls collects the names of the templates called by {{template}} actions under node.
+func templateCalls(node parse.Node, calls map[string]bool) {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node != nil {
+			for _, child := range node.Nodes {
+				templateCalls(child, calls)
+			}
+		}
+	case *parse.IfNode:
+		templateCalls(node.List, calls)
+		templateCalls(node.ElseList, calls)
+	case *parse.RangeNode:
+		templateCalls(node.List, calls)
+		templateCalls(node.ElseList, calls)
+	case *parse.WithNode:
+		templateCalls(node.List, calls)
+		templateCalls(node.ElseList, calls)
+	case *parse.TemplateNode:
+		calls[node.Name] = true
+	}
+}
+
+// templateSet holds the templates created by a factory. The templates are never executed directly:
+// each rendering uses an instance, that is a clone of the templates bound to a layout and a page.
+// Instances are pooled as html/template escapes a template the first time it is executed.
+type templateSet struct {
+	templates *template.Template
+	// scoped maps a template file to the names of the templates defined inside it.
+	scoped    map[string][]string
+	instances sync.Map
+}
+
+func newTemplateSet(templates *template.Template) *templateSet {
+	set := &templateSet{
+		templates: templates,
+		scoped:    make(map[string][]string),
+	}
+	for _, tmpl := range templates.Templates() {
+		if index := strings.Index(tmpl.Name(), scopeSeparator); index >= 0 {
+			file := tmpl.Name()[:index]
+			set.scoped[file] = append(set.scoped[file], tmpl.Name()[index+len(scopeSeparator):])
+		}
+	}
+	return set
+}
+
+// templateInstance is a clone of the templates whose layout functions are bound to its state.
+type templateInstance struct {
+	templates *template.Template
+	layout    string
+	page      string
+	binding   interface{}
+}
+
+// execute renders the page, inside the layout if any, in the buffer.
+// The functions, if any, replace the functions of the same name for this rendering and the next ones of the instance.
+func (set *templateSet) execute(out *bytes.Buffer, layout string, page string, binding interface{}, functions template.FuncMap) error {
+	key := layout + "\x00" + page
+	pool, _ := set.instances.LoadOrStore(key, &sync.Pool{})
+	instance, _ := pool.(*sync.Pool).Get().(*templateInstance)
+	if instance == nil {
+		var err error
+		if instance, err = set.instantiate(layout, page); err != nil {
+			return err
+		}
+	}
+	defer pool.(*sync.Pool).Put(instance)
+
+	if len(functions) > 0 {
+		instance.templates.Funcs(functions)
+	}
+	instance.binding = binding
+	defer func() { instance.binding = nil }()
+	if layout == "" {
+		return instance.templates.ExecuteTemplate(out, page, binding)
+	}
+	return instance.templates.ExecuteTemplate(out, layout, binding)
+}
+
+// instantiate clones the templates, restores the default definition of the blocks, makes the templates
+// defined by the layout then by the page take precedence, and binds the layout functions.
+func (set *templateSet) instantiate(layout string, page string) (*templateInstance, error) {
+	if layout != "" && set.templates.Lookup(layout) == nil {
+		return nil, fmt.Errorf("render: no layout %q", layout)
+	}
+	if set.templates.Lookup(page) == nil {
+		return nil, fmt.Errorf("render: no template %q", page)
+	}
+
+	templates, err := set.templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range []string{"", layout, page} {
+		for _, name := range set.scoped[file] {
+			tree := set.templates.Lookup(scopedName(file, name)).Tree.Copy()
+			if parse.IsEmptyTree(tree.Root) {
+				// An empty template does not replace an existing one: use an equivalent non-empty one.
+				tree.Root = emptyOutput.CopyList()
+			}
+			if _, err := templates.AddParseTree(name, tree); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	instance := &templateInstance{templates: templates, layout: layout, page: page}
+	templates.Funcs(template.FuncMap{
+		"yield":   instance.yield,
+		"partial": instance.partial,
+		"current": instance.current,
+	})
+	return instance, nil
+}
+
+// emptyOutput is a template body that is not considered empty by the parser, but which outputs nothing.
+var emptyOutput = texttemplate.Must(texttemplate.New("empty").Parse("{{if false}}{{end}}")).Tree.Root
+
+var errYieldWithoutLayout = errors.New("render: yield can only be called from a layout")
+
+func (instance *templateInstance) yield() (template.HTML, error) {
+	if instance.layout == "" {
+		return "", errYieldWithoutLayout
+	}
+	return instance.executeHTML(instance.page)
+}
+
+// partial renders the template "name-page" if it exists, otherwise the template name if it exists.
+func (instance *templateInstance) partial(name string) (template.HTML, error) {
+	if instance.templates.Lookup(name+"-"+instance.page) != nil {
+		return instance.executeHTML(name + "-" + instance.page)
+	}
+	if instance.templates.Lookup(name) != nil {
+		return instance.executeHTML(name)
+	}
+	return "", nil
+}
+
+func (instance *templateInstance) current() string {
+	return instance.page
+}
+
+func (instance *templateInstance) executeHTML(name string) (template.HTML, error) {
+	out := new(bytes.Buffer)
+	if err := instance.templates.ExecuteTemplate(out, name, instance.binding); err != nil {
+		return "", err
+	}
+	return template.HTML(out.String()), nil
+}
@@ -0,0 +1,133 @@
+package render
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Layout_Render_WithDefaultLayout_ShouldYieldTheTemplate(t *testing.T) {
+	recorder, err := renderTemplate(layoutTemplateRender().SetLayout("layout"), http.StatusOK, "hello", "World")
+	expect(t, err, nil)
+	expect(t, recorder.Body.String(), `<html><h1>Hello World</h1></html>`)
+}
+
+func Test_Layout_Render_WithoutLayout_ShouldRenderTheTemplateAlone(t *testing.T) {
+	recorder, _ := renderTemplate(layoutTemplateRender(), http.StatusOK, "hello", "World")
+	expect(t, recorder.Body.String(), `<h1>Hello World</h1>`)
+}
+
+func Test_Layout_RenderLayout_ShouldOverrideTheDefaultLayout(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	layoutTemplateRender().SetLayout("layout").RenderLayout(recorder, http.StatusOK, "admin", "hello", "World")
+	expect(t, recorder.Body.String(), `<admin><h1>Hello World</h1></admin>`)
+	recorder = httptest.NewRecorder()
+	layoutTemplateRender().SetLayout("layout").RenderLayout(recorder, http.StatusOK, "", "hello", "World")
+	expect(t, recorder.Body.String(), `<h1>Hello World</h1>`)
+}
+
+func Test_Layout_Respond_ShouldUseTheLayoutOption(t *testing.T) {
+	recorder, _ := respond(layoutTemplateRender().SetLayout("layout"), http.StatusOK, "World", Options{Template: "hello", Layout: "admin"})
+	expect(t, recorder.Body.String(), `<admin><h1>Hello World</h1></admin>`)
+}
+
+func Test_Layout_Current_ShouldReturnTheTemplateName(t *testing.T) {
+	factory := NewStaticTemplateFactory("static").SetSubTemplates(
+		func() (string, string) { return "layout", `<body class="{{ current }}">{{ yield }}</body>` },
+		hello_template)
+	recorder, _ := renderTemplate(NewTemplateRender().SetFactory(factory).SetLayout("layout"), http.StatusOK, "hello", "World")
+	expect(t, recorder.Body.String(), `<body class="hello"><h1>Hello World</h1></body>`)
+}
+
+func Test_Layout_Partial_ShouldPreferTheTemplateSpecificPartial(t *testing.T) {
+	factory := NewStaticTemplateFactory("static").SetSubTemplates(
+		func() (string, string) { return "layout", `{{ partial "header" }}|{{ yield }}` },
+		func() (string, string) { return "header", `default {{.}}` },
+		func() (string, string) { return "header-main", `main {{.}}` },
+		hello_template, main_template)
+	render := NewTemplateRender().SetFactory(factory).SetLayout("layout")
+	recorder, _ := renderTemplate(render, http.StatusOK, "main", "World")
+	expect(t, recorder.Body.String(), `main World|<h1>Main</h1>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "hello", "World")
+	expect(t, recorder.Body.String(), `default World|<h1>Hello World</h1>`)
+}
+
+func Test_Layout_Partial_WithUnknownPartial_ShouldRenderNothing(t *testing.T) {
+	factory := NewStaticTemplateFactory("static").SetSubTemplates(
+		func() (string, string) { return "layout", `[{{ partial "unknown" }}]{{ yield }}` },
+		main_template)
+	recorder, _ := renderTemplate(NewTemplateRender().SetFactory(factory).SetLayout("layout"), http.StatusOK, "main", nil)
+	expect(t, recorder.Body.String(), `[]<h1>Main</h1>`)
+}
+
+func Test_Layout_Render_WithYieldAndNoLayout_ShouldFail(t *testing.T) {
+	factory := NewStaticTemplateFactory("static").SetSubTemplates(func() (string, string) { return "page", `{{ yield }}` })
+	recorder, err := renderTemplate(NewTemplateRender().SetFactory(factory), http.StatusOK, "page", nil)
+	refute(t, err, nil)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Layout_Render_WithUnknownLayout_ShouldFail(t *testing.T) {
+	recorder, err := renderTemplate(layoutTemplateRender().SetLayout("unknown"), http.StatusCreated, "hello", nil)
+	refute(t, err, nil)
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Layout_Blocks_ShouldBeRedefinedByEachPage(t *testing.T) {
+	factory := NewStaticTemplateFactory("static").SetSubTemplates(
+		func() (string, string) {
+			return "layout", `<title>{{block "title" .}}Site{{end}}</title>{{ yield }}`
+		},
+		func() (string, string) { return "home", `{{define "title"}}Home{{end}}<p>home</p>` },
+		func() (string, string) { return "about", `{{define "title"}}About{{end}}<p>about</p>` },
+		func() (string, string) { return "contact", `<p>contact</p>` })
+	render := NewTemplateRender().SetFactory(factory).SetLayout("layout")
+	recorder, _ := renderTemplate(render, http.StatusOK, "home", nil)
+	expect(t, recorder.Body.String(), `<title>Home</title><p>home</p>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "about", nil)
+	expect(t, recorder.Body.String(), `<title>About</title><p>about</p>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "contact", nil)
+	expect(t, recorder.Body.String(), `<title>Site</title><p>contact</p>`)
+}
+
+func Test_Layout_Blocks_ShouldWorkAcrossFilesLoadedFromDisk(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{
+		"base.tmpl":        `<title>{{block "title" .}}Site{{end}}</title>{{block "content" .}}{{end}}`,
+		"pages/home.tmpl":  `{{template "base" .}}{{define "title"}}Home{{end}}{{define "content"}}<p>{{.}}</p>{{end}}`,
+		"pages/about.tmpl": `{{template "base" .}}{{define "title"}}About{{end}}`,
+	})
+	render := NewTemplateRender().SetFactory(NewDiskTemplateFactory().SetDirectory(directory))
+	recorder, err := renderTemplate(render, http.StatusOK, "pages/home", "welcome")
+	expect(t, err, nil)
+	expect(t, recorder.Body.String(), `<title>Home</title><p>welcome</p>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "pages/about", nil)
+	expect(t, recorder.Body.String(), `<title>About</title>`)
+}
+
+func layoutTemplateRender() *TemplateRender {
+	return NewTemplateRender().SetFactory(NewStaticTemplateFactory("static").SetSubTemplates(
+		func() (string, string) { return "layout", `<html>{{ yield }}</html>` },
+		func() (string, string) { return "admin", `<admin>{{ yield }}</admin>` },
+		main_template, hello_template))
+}
+
+func writeTemplateFiles(t *testing.T, files map[string]string) string {
+	directory, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return directory
+}
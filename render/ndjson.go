@@ -0,0 +1,91 @@
+package render
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// NDJSON built-in renderer, writing newline delimited JSON: one JSON value per line.
+type NDJSONRender struct {
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+	// FlushEvery is the number of lines written between two flushes by RenderIterator. Default is 1.
+	FlushEvery int
+}
+
+func (render *NDJSONRender) SetContentType(value string) *NDJSONRender {
+	render.ContentType = value
+	return render
+}
+
+func (render *NDJSONRender) SetCharset(value string) *NDJSONRender {
+	render.Charset = value
+	return render
+}
+
+func (render *NDJSONRender) SetHeaderPolicy(value HeaderPolicy) *NDJSONRender {
+	render.HeaderPolicy = value
+	return render
+}
+
+func (render *NDJSONRender) SetFlushEvery(value int) *NDJSONRender {
+	render.FlushEvery = value
+	return render
+}
+
+// NewNDJSONRender creates a new NDJSON render with default values.
+func NewNDJSONRender() *NDJSONRender {
+	return &NDJSONRender{
+		ContentType: "application/x-ndjson",
+		Charset:     "UTF-8",
+		FlushEvery:  1,
+	}
+}
+
+// Render a NDJSON response: each element of a slice or an array on its own line, or any other value on a
+// single line. A []byte, or a value encoding itself like json.RawMessage, is a single value. All the lines are
+// encoded before anything is written.
+func (render *NDJSONRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	value := reflect.ValueOf(v)
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && !isSingleJSONValue(value) {
+		for i := 0; i < value.Len(); i++ {
+			if err := encoder.Encode(value.Index(i).Interface()); err != nil {
+				return encodeError(fmt.Errorf("element %d: %v", i, err))
+			}
+		}
+	} else if err := encoder.Encode(v); err != nil {
+		return encodeError(err)
+	}
+
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, 0, buffer.Bytes())
+}
+
+// isSingleJSONValue returns true for the slices that encoding/json does not encode as an array: []byte, encoded
+// in base64, and the values implementing json.Marshaler or encoding.TextMarshaler.
+func isSingleJSONValue(value reflect.Value) bool {
+	switch value.Interface().(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8
+}
+
+// RenderIterator streams the values returned by next, one per line.
+// The response is flushed every FlushEvery lines and at the end.
+func (render *NDJSONRender) RenderIterator(writer http.ResponseWriter, status int, next Iterator) error {
+	lines := &JSONRender{
+		ContentType:  render.ContentType,
+		Charset:      render.Charset,
+		HeaderPolicy: render.HeaderPolicy,
+		FlushEvery:   render.FlushEvery,
+	}
+	return lines.RenderLines(writer, status, next)
+}
@@ -0,0 +1,68 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_NDJSON_Render_ShouldWriteOneElementPerLine(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewNDJSONRender().Render(recorder, http.StatusOK, []JSONGreeting{{"hello", "world"}, {"good", "bye"}})
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/x-ndjson; charset=UTF-8")
+	expect(t, recorder.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}\n{\"one\":\"good\",\"two\":\"bye\"}\n")
+}
+
+func Test_NDJSON_Render_WithSingleValue_ShouldWriteOneLine(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewNDJSONRender().Render(recorder, http.StatusCreated, JSONGreeting{"hello", "world"})
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}\n")
+}
+
+func Test_NDJSON_Render_WithBytesOrRawJSON_ShouldWriteOneLine(t *testing.T) {
+	render := func(v interface{}) string {
+		recorder := httptest.NewRecorder()
+		expect(t, NewNDJSONRender().Render(recorder, http.StatusOK, v), nil)
+		return recorder.Body.String()
+	}
+	expect(t, render(json.RawMessage(`{"a":1}`)), "{\"a\":1}\n")
+	expect(t, render([]byte("abc")), "\"YWJj\"\n")
+	expect(t, render(net.IPv4(127, 0, 0, 1)), "\"127.0.0.1\"\n")
+}
+
+func Test_NDJSON_Render_WhenAnElementCannotBeEncoded_ShouldWriteNothing(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewNDJSONRender().Render(recorder, http.StatusOK, []float64{1, math.NaN()})
+	expectPhase(t, err, EncodePhase)
+	expect(t, err.Error(), "render: encode: element 1: json: unsupported value: NaN")
+	expect(t, recorder.Body.String(), "")
+}
+
+func Test_NDJSON_Render_WithSpecificContentType_ShouldSetTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewNDJSONRender().SetContentType("application/jsonl").SetCharset("UTF-16").Render(recorder, http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/jsonl; charset=UTF-16")
+	expect(t, recorder.Body.String(), "null\n")
+}
+
+func Test_NDJSON_RenderIterator_ShouldStreamTheLines(t *testing.T) {
+	writer := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	err := NewNDJSONRender().SetFlushEvery(2).RenderIterator(writer, http.StatusOK, SliceIterator([]int{1, 2, 3}))
+	expect(t, err, nil)
+	expect(t, writer.Body.String(), "1\n2\n3\n")
+	expect(t, writer.Header().Get("Content-Type"), "application/x-ndjson; charset=UTF-8")
+	expect(t, writer.count, 2)
+}
+
+func Test_NDJSON_RenderIterator_WhenWriteFails_ShouldReturnTheError(t *testing.T) {
+	failure := errors.New("failure")
+	err := NewNDJSONRender().RenderIterator(newFailingWriter(0, failure), http.StatusOK, SliceIterator([]int{1}))
+	expectPhase(t, err, BodyPhase)
+	expect(t, errors.Is(err, failure), true)
+}
@@ -0,0 +1,148 @@
+package render
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned by Negotiation when none of the offered media types is accepted by the request,
+// and the negotiation is strict. Only the Vary header has been set: the handler usually responds with a 406
+// status.
+var ErrNotAcceptable = errors.New("render: not acceptable")
+
+// Negotiation chooses the renderer of a response according to the Accept header of the request, among the
+// renderers offered for some media types:
+//
+//	negotiation := render.NewNegotiation().
+//		Offer("application/json", render.NewJSONRender()).
+//		Offer("application/msgpack", binary.NewMsgPackRender())
+//	err := negotiation.Respond(writer, request, http.StatusOK, value, render.Options{})
+//
+// The offer with the highest quality in the Accept header wins, the first one in case of tie. A media type
+// matches its exact media range with priority over type/* and */*; the parameters of the media types are ignored.
+type Negotiation struct {
+	offers []offer
+	// Strict defines whether ErrNotAcceptable is returned when no offer is accepted. Otherwise the first offer
+	// is used. Default is false.
+	Strict bool
+}
+
+type offer struct {
+	mediaType string
+	renderer  Renderer
+}
+
+// NewNegotiation creates a new Negotiation without offer.
+func NewNegotiation() *Negotiation {
+	return &Negotiation{}
+}
+
+// Offer adds a renderer for the media type. The renderer is expected to produce this media type.
+func (negotiation *Negotiation) Offer(mediaType string, renderer Renderer) *Negotiation {
+	negotiation.offers = append(negotiation.offers, offer{mediaType: strings.ToLower(mediaType), renderer: renderer})
+	return negotiation
+}
+
+func (negotiation *Negotiation) SetStrict(value bool) *Negotiation {
+	negotiation.Strict = value
+	return negotiation
+}
+
+// Negotiate returns the media type and the renderer offered for the request, and false if the request accepts
+// none of the offers.
+func (negotiation *Negotiation) Negotiate(request *http.Request) (string, Renderer, bool) {
+	if len(negotiation.offers) == 0 {
+		return "", nil, false
+	}
+	header := strings.Join(request.Header.Values("Accept"), ",")
+	if strings.TrimSpace(header) == "" {
+		return negotiation.offers[0].mediaType, negotiation.offers[0].renderer, true
+	}
+
+	ranges := parseAccept(header)
+	best, bestQuality := -1, 0.0
+	for i, offer := range negotiation.offers {
+		if quality := acceptQuality(ranges, offer.mediaType); quality > bestQuality {
+			best, bestQuality = i, quality
+		}
+	}
+	if best < 0 {
+		return negotiation.offers[0].mediaType, negotiation.offers[0].renderer, false
+	}
+	return negotiation.offers[best].mediaType, negotiation.offers[best].renderer, true
+}
+
+// Respond renders the value with the renderer negotiated for the request. The request is added to the options.
+// The Vary header of the response includes Accept, even when the negotiation fails, so that the caches do not
+// serve a 406 response to the clients accepting another media type.
+func (negotiation *Negotiation) Respond(writer http.ResponseWriter, request *http.Request, status int, v interface{}, options Options) error {
+	writer.Header().Add("Vary", "Accept")
+	_, renderer, ok := negotiation.Negotiate(request)
+	if renderer == nil || (!ok && negotiation.Strict) {
+		return ErrNotAcceptable
+	}
+	options.Request = request
+	return renderer.Respond(writer, status, v, options)
+}
+
+// mediaRange is a media range of an Accept header.
+type mediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// parseAccept parses the media ranges of an Accept header. The invalid ranges are ignored.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		slash := strings.Index(mediaType, "/")
+		if slash < 0 {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mainType: mediaType[:slash], subType: mediaType[slash+1:], quality: quality})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific media range matching the media type, 0 if none.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	mediaType, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return 0
+	}
+	mainType, subType := mediaType, ""
+	if slash := strings.Index(mediaType, "/"); slash >= 0 {
+		mainType, subType = mediaType[:slash], mediaType[slash+1:]
+	}
+
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		current := -1
+		switch {
+		case r.mainType == mainType && r.subType == subType:
+			current = 2
+		case r.mainType == mainType && r.subType == "*":
+			current = 1
+		case r.mainType == "*" && r.subType == "*":
+			current = 0
+		}
+		if current > specificity {
+			quality, specificity = r.quality, current
+		}
+	}
+	return quality
+}
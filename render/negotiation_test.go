@@ -0,0 +1,105 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// binaryNegotiation offers JSON and the media types of binary encodings, rendered as raw data.
+func binaryNegotiation() *Negotiation {
+	return NewNegotiation().
+		Offer("application/json", NewJSONRender()).
+		Offer("application/msgpack", NewDataRender().SetContentType("application/msgpack").SetCharset("")).
+		Offer("application/cbor", NewDataRender().SetContentType("application/cbor").SetCharset(""))
+}
+
+func negotiationRequest(accept ...string) *http.Request {
+	request := httptest.NewRequest("GET", "/", nil)
+	for _, value := range accept {
+		request.Header.Add("Accept", value)
+	}
+	return request
+}
+
+func Test_Negotiation_Negotiate_ShouldChooseTheBestOffer(t *testing.T) {
+	negotiation := binaryNegotiation()
+	for accept, expected := range map[string]string{
+		"application/msgpack":                                      "application/msgpack",
+		"application/cbor, application/msgpack":                    "application/msgpack",
+		"application/cbor, application/msgpack;q=0.5":              "application/cbor",
+		"text/html, application/*;q=0.2, application/cbor":         "application/cbor",
+		"application/*":                                            "application/json",
+		"*/*":                                                      "application/json",
+		"application/*;q=0.5, application/json;q=0.1":              "application/msgpack",
+		"Application/CBOR":                                         "application/cbor",
+		"application/msgpack; version=2":                           "application/msgpack",
+		"invalid, application/cbor;q=2, application/msgpack;q=0.1": "application/msgpack",
+	} {
+		mediaType, _, ok := negotiation.Negotiate(negotiationRequest(accept))
+		expect(t, ok, true)
+		if mediaType != expected {
+			t.Errorf("Expected %s for %q, got %s.", expected, accept, mediaType)
+		}
+	}
+}
+
+func Test_Negotiation_Negotiate_WithoutAcceptHeader_ShouldChooseTheFirstOffer(t *testing.T) {
+	mediaType, _, ok := binaryNegotiation().Negotiate(negotiationRequest())
+	expect(t, ok, true)
+	expect(t, mediaType, "application/json")
+}
+
+func Test_Negotiation_Negotiate_ShouldCombineTheAcceptHeaders(t *testing.T) {
+	mediaType, _, _ := binaryNegotiation().Negotiate(negotiationRequest("text/html", "application/cbor"))
+	expect(t, mediaType, "application/cbor")
+}
+
+func Test_Negotiation_Negotiate_WhenNothingIsAccepted_ShouldFail(t *testing.T) {
+	_, _, ok := binaryNegotiation().Negotiate(negotiationRequest("text/html, application/json;q=0"))
+	expect(t, ok, false)
+	_, renderer, ok := NewNegotiation().Negotiate(negotiationRequest())
+	expect(t, ok, false)
+	expect(t, renderer, nil)
+}
+
+func Test_Negotiation_Respond_ShouldRenderWithTheNegotiatedRenderer(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := binaryNegotiation().Respond(recorder, negotiationRequest("application/msgpack"), http.StatusOK, "data", Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/msgpack")
+	expect(t, recorder.Header().Get("Vary"), "Accept")
+	expect(t, recorder.Body.String(), "data")
+
+	recorder = httptest.NewRecorder()
+	binaryNegotiation().Respond(recorder, negotiationRequest("application/json"), http.StatusOK, "data", Options{})
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `"data"`)
+}
+
+func Test_Negotiation_Respond_WhenNothingIsAccepted_ShouldUseTheFirstOffer(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := binaryNegotiation().Respond(recorder, negotiationRequest("text/html"), http.StatusOK, 1, Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+}
+
+func Test_Negotiation_Respond_WhenStrictAndNothingIsAccepted_ShouldOnlySetVary(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := binaryNegotiation().SetStrict(true).Respond(recorder, negotiationRequest("text/html"), http.StatusOK, 1, Options{})
+	expect(t, err, ErrNotAcceptable)
+	expect(t, recorder.Body.Len(), 0)
+	expect(t, len(recorder.Header()), 1)
+	expect(t, recorder.Header().Get("Vary"), "Accept")
+}
+
+func Test_Negotiation_Respond_ShouldPassTheRequestInTheOptions(t *testing.T) {
+	var options Options
+	request := negotiationRequest()
+	NewNegotiation().Offer("text/plain", RendererFunc(func(writer http.ResponseWriter, status int, v interface{}, o Options) error {
+		options = o
+		return nil
+	})).Respond(httptest.NewRecorder(), request, http.StatusOK, nil, Options{Template: "t"})
+	expect(t, options.Request, request)
+	expect(t, options.Template, "t")
+}
@@ -0,0 +1,337 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Problem is a problem details object, as defined by RFC 9457 (formerly RFC 7807), describing an error in a
+// HTTP response. It implements the error interface, so that a handler can return a problem as an error.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. Empty means "about:blank".
+	Type string
+	// Title is a short summary of the problem type.
+	Title string
+	// Status is the HTTP status code of the response.
+	Status int
+	// Detail explains this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string
+	// Extensions are additional members. They cannot replace the standard members.
+	Extensions map[string]interface{}
+}
+
+// NewProblem creates a problem for the status, whose title is the status text.
+func NewProblem(status int) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+}
+
+func (problem *Problem) SetType(value string) *Problem {
+	problem.Type = value
+	return problem
+}
+
+func (problem *Problem) SetTitle(value string) *Problem {
+	problem.Title = value
+	return problem
+}
+
+func (problem *Problem) SetDetail(value string) *Problem {
+	problem.Detail = value
+	return problem
+}
+
+func (problem *Problem) SetInstance(value string) *Problem {
+	problem.Instance = value
+	return problem
+}
+
+// SetExtension sets an additional member.
+func (problem *Problem) SetExtension(name string, value interface{}) *Problem {
+	if problem.Extensions == nil {
+		problem.Extensions = make(map[string]interface{})
+	}
+	problem.Extensions[name] = value
+	return problem
+}
+
+func (problem *Problem) Error() string {
+	message := problem.Title
+	if message == "" {
+		message = http.StatusText(problem.Status)
+	}
+	if problem.Detail != "" {
+		message += ": " + problem.Detail
+	}
+	return message
+}
+
+// standardProblem holds the standard members of a problem, in their order of appearance.
+type standardProblem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+var standardMembers = map[string]bool{"type": true, "title": true, "status": true, "detail": true, "instance": true}
+
+func (problem Problem) standard() standardProblem {
+	return standardProblem{problem.Type, problem.Title, problem.Status, problem.Detail, problem.Instance}
+}
+
+// extensionNames returns the names of the extensions, sorted, without the standard members.
+func (problem Problem) extensionNames() []string {
+	var names []string
+	for name := range problem.Extensions {
+		if !standardMembers[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarshalJSON encodes the standard members followed by the extensions sorted by name.
+func (problem Problem) MarshalJSON() ([]byte, error) {
+	result, err := json.Marshal(problem.standard())
+	if err != nil {
+		return nil, err
+	}
+	buffer := bytes.NewBuffer(result[:len(result)-1])
+	for _, name := range problem.extensionNames() {
+		value, err := json.Marshal(problem.Extensions[name])
+		if err != nil {
+			return nil, fmt.Errorf("problem extension %s: %v", name, err)
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the standard members, and the other members as extensions.
+func (problem *Problem) UnmarshalJSON(data []byte) error {
+	var standard standardProblem
+	if err := json.Unmarshal(data, &standard); err != nil {
+		return err
+	}
+	var members map[string]interface{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*problem = Problem{standard.Type, standard.Title, standard.Status, standard.Detail, standard.Instance, nil}
+	for name, value := range members {
+		if !standardMembers[name] {
+			problem.SetExtension(name, value)
+		}
+	}
+	return nil
+}
+
+// problemNamespace is the XML namespace of the problem details, defined by RFC 7807.
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// MarshalXML encodes the problem as a problem element of the RFC 7807 namespace, with an element per member.
+// The extensions are encoded with encoding/xml, sorted by name.
+func (problem Problem) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	members := []struct {
+		name  string
+		value interface{}
+		empty bool
+	}{
+		{"type", problem.Type, problem.Type == ""},
+		{"title", problem.Title, problem.Title == ""},
+		{"status", problem.Status, problem.Status == 0},
+		{"detail", problem.Detail, problem.Detail == ""},
+		{"instance", problem.Instance, problem.Instance == ""},
+	}
+	for _, member := range members {
+		if member.empty {
+			continue
+		}
+		if err := encoder.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Local: member.name}}); err != nil {
+			return err
+		}
+	}
+	for _, name := range problem.extensionNames() {
+		if err := encoder.EncodeElement(problem.Extensions[name], xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return fmt.Errorf("problem extension %s: %v", name, err)
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// ProblemMapper converts an error to a problem, or returns nil if it does not handle the error.
+type ProblemMapper func(err error) *Problem
+
+// ErrorIs creates a ProblemMapper converting the errors matching target with errors.Is to a copy of problem.
+func ErrorIs(target error, problem Problem) ProblemMapper {
+	return func(err error) *Problem {
+		if errors.Is(err, target) {
+			result := problem
+			return &result
+		}
+		return nil
+	}
+}
+
+// ErrorAs creates a ProblemMapper converting the errors having an error of the same type as example in their
+// chain, found with errors.As, using convert. The example must be typed, it panics with an untyped nil:
+//
+//	render.ErrorAs((*os.PathError)(nil), func(err error) *render.Problem {
+//		return render.NewProblem(http.StatusNotFound).SetDetail(err.(*os.PathError).Path)
+//	})
+func ErrorAs(example error, convert func(err error) *Problem) ProblemMapper {
+	if example == nil {
+		panic("render: ErrorAs expects a typed example, like (*os.PathError)(nil), got nil")
+	}
+	errorType := reflect.TypeOf(example)
+	return func(err error) *Problem {
+		target := reflect.New(errorType)
+		if errors.As(err, target.Interface()) {
+			return convert(target.Elem().Interface().(error))
+		}
+		return nil
+	}
+}
+
+// ProblemRender renders problems, in JSON or in XML according to the Accept header of the request, and
+// converts errors to problems.
+type ProblemRender struct {
+	// JSON renders the problems in JSON. Default content type is application/problem+json.
+	JSON *JSONRender
+	// XML renders the problems in XML. Default content type is application/problem+xml.
+	XML *XMLRender
+	// Mappers convert the errors to problems. The first one returning a problem wins.
+	Mappers []ProblemMapper
+}
+
+// NewProblemRender creates a new problem render with default values.
+func NewProblemRender() *ProblemRender {
+	return &ProblemRender{
+		JSON: NewJSONRender().SetContentType("application/problem+json").SetHeaderPolicy(OverrideContentType),
+		XML:  NewXMLRender().SetContentType("application/problem+xml").SetHeaderPolicy(OverrideContentType),
+	}
+}
+
+func (render *ProblemRender) SetJSON(value *JSONRender) *ProblemRender {
+	render.JSON = value
+	return render
+}
+
+func (render *ProblemRender) SetXML(value *XMLRender) *ProblemRender {
+	render.XML = value
+	return render
+}
+
+// AddMappers adds mappers after the existing ones.
+func (render *ProblemRender) AddMappers(mappers ...ProblemMapper) *ProblemRender {
+	render.Mappers = append(render.Mappers, mappers...)
+	return render
+}
+
+// Render a problem in JSON. The status of the response is the status of the problem, 500 if not set.
+func (render *ProblemRender) Render(writer http.ResponseWriter, problem *Problem) error {
+	return render.JSON.Render(writer, problemStatus(problem), problem)
+}
+
+// RenderRequest renders a problem in XML if the request prefers XML to JSON, in JSON otherwise.
+func (render *ProblemRender) RenderRequest(writer http.ResponseWriter, request *http.Request, problem *Problem) error {
+	return render.negotiation().Respond(writer, request, problemStatus(problem), problem, Options{})
+}
+
+// RenderError renders the problem corresponding to the error, see Problem.
+func (render *ProblemRender) RenderError(writer http.ResponseWriter, request *http.Request, err error) error {
+	return render.RenderRequest(writer, request, render.Problem(err))
+}
+
+// HandleError renders the problem corresponding to the error, ignoring the rendering errors. It can be used as
+// the error handler of the Recovery middleware.
+func (render *ProblemRender) HandleError(writer http.ResponseWriter, request *http.Request, err error) {
+	render.RenderError(writer, request, err)
+}
+
+// Handler returns a handler rendering the problem, for instance as the ErrorHandler of a ContentTypeChecker.
+func (render *ProblemRender) Handler(problem *Problem) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		render.RenderRequest(writer, request, problem)
+	})
+}
+
+// Problem converts an error to a problem with the first mapper handling it. Otherwise, the problem found in the
+// chain of the error with errors.As is used, or a problem with the status 500 which does not disclose the error.
+func (render *ProblemRender) Problem(err error) *Problem {
+	for _, mapper := range render.Mappers {
+		if problem := mapper(err); problem != nil {
+			return problem
+		}
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+	return NewProblem(http.StatusInternalServerError)
+}
+
+// Respond implements the Renderer interface. The value must be a *Problem, a Problem or an error, and the
+// format is negotiated when the options have a request. The status is used when the problem has none.
+func (render *ProblemRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	var problem *Problem
+	switch value := v.(type) {
+	case *Problem:
+		problem = value
+	case Problem:
+		problem = &value
+	case error:
+		problem = render.Problem(value)
+	default:
+		return fmt.Errorf("render: ProblemRender cannot render a %T", v)
+	}
+	if problem.Status == 0 {
+		copy := *problem
+		copy.Status = status
+		problem = &copy
+	}
+	if options.Request == nil {
+		return render.Render(writer, problem)
+	}
+	return render.RenderRequest(writer, options.Request, problem)
+}
+
+func (render *ProblemRender) negotiation() *Negotiation {
+	return NewNegotiation().
+		Offer("application/problem+json", render.JSON).
+		Offer("application/json", render.JSON).
+		Offer("application/problem+xml", render.XML).
+		Offer("application/xml", render.XML).
+		Offer("text/xml", render.XML)
+}
+
+func problemStatus(problem *Problem) int {
+	if problem.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return problem.Status
+}
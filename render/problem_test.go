@@ -0,0 +1,161 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Problem_MarshalJSON_ShouldWriteTheStandardMembersThenTheSortedExtensions(t *testing.T) {
+	problem := NewProblem(http.StatusForbidden).
+		SetType("https://example.com/probs/out-of-credit").
+		SetDetail("Your current balance is 30, but that costs 50.").
+		SetInstance("/account/12345/msgs/abc").
+		SetExtension("balance", 30).
+		SetExtension("accounts", []string{"/account/12345"}).
+		SetExtension("status", 200)
+	data, err := json.Marshal(problem)
+	expect(t, err, nil)
+	expect(t, string(data), `{"type":"https://example.com/probs/out-of-credit","title":"Forbidden","status":403,`+
+		`"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",`+
+		`"accounts":["/account/12345"],"balance":30}`)
+}
+
+func Test_Problem_MarshalJSON_WithoutMember_ShouldWriteAnEmptyObject(t *testing.T) {
+	data, err := json.Marshal(Problem{Extensions: map[string]interface{}{"code": "E1"}})
+	expect(t, err, nil)
+	expect(t, string(data), `{"code":"E1"}`)
+}
+
+func Test_Problem_UnmarshalJSON_ShouldReadTheExtensions(t *testing.T) {
+	var problem Problem
+	err := json.Unmarshal([]byte(`{"title":"Not Found","status":404,"resource":"user"}`), &problem)
+	expect(t, err, nil)
+	expect(t, problem.Title, "Not Found")
+	expect(t, problem.Status, 404)
+	expect(t, len(problem.Extensions), 1)
+	expect(t, problem.Extensions["resource"], "user")
+}
+
+func Test_Problem_Error_ShouldDescribeTheProblem(t *testing.T) {
+	expect(t, NewProblem(http.StatusNotFound).Error(), "Not Found")
+	expect(t, NewProblem(http.StatusNotFound).SetDetail("no user 12").Error(), "Not Found: no user 12")
+	expect(t, (&Problem{Status: http.StatusConflict}).Error(), "Conflict")
+}
+
+func Test_ProblemRender_Render_ShouldRenderProblemJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "text/html")
+	err := NewProblemRender().Render(recorder, NewProblem(http.StatusNotFound))
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+json; charset=UTF-8")
+	expect(t, recorder.Body.String(), `{"title":"Not Found","status":404}`)
+}
+
+func Test_ProblemRender_Render_WithoutStatus_ShouldRespondInternalServerError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewProblemRender().Render(recorder, &Problem{Title: "Oops"})
+	expect(t, recorder.Code, http.StatusInternalServerError)
+}
+
+func Test_ProblemRender_RenderRequest_WhenXMLIsPreferred_ShouldRenderProblemXML(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	problem := NewProblem(http.StatusBadRequest).SetDetail("a < b").SetExtension("field", "name")
+	err := NewProblemRender().RenderRequest(recorder, negotiationRequest("application/xml, application/json;q=0.5"), problem)
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusBadRequest)
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+xml; charset=UTF-8")
+	expect(t, recorder.Header().Get("Vary"), "Accept")
+	expect(t, recorder.Body.String(), `<problem xmlns="urn:ietf:rfc:7807"><title>Bad Request</title><status>400</status>`+
+		`<detail>a &lt; b</detail><field>name</field></problem>`)
+}
+
+func Test_ProblemRender_RenderRequest_ShouldDefaultToJSON(t *testing.T) {
+	for _, accept := range []string{"", "*/*", "text/html", "application/problem+json"} {
+		recorder := httptest.NewRecorder()
+		NewProblemRender().RenderRequest(recorder, negotiationRequest(accept), NewProblem(http.StatusConflict))
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "application/problem+json; charset=UTF-8" {
+			t.Errorf("Expected JSON for %q, got %s.", accept, contentType)
+		}
+	}
+}
+
+var errProblemTest = errors.New("test: missing")
+
+func Test_ProblemRender_Problem_ShouldUseTheFirstMatchingMapper(t *testing.T) {
+	render := NewProblemRender().AddMappers(
+		ErrorIs(errProblemTest, *NewProblem(http.StatusNotFound)),
+		ErrorAs((*os.PathError)(nil), func(err error) *Problem {
+			return NewProblem(http.StatusGone).SetDetail(err.(*os.PathError).Path)
+		}),
+		ErrorIs(os.ErrNotExist, *NewProblem(http.StatusBadRequest)),
+	)
+
+	expect(t, render.Problem(fmt.Errorf("loading: %w", errProblemTest)).Status, http.StatusNotFound)
+	_, err := os.Open("/does/not/exist")
+	problem := render.Problem(fmt.Errorf("loading: %w", err))
+	expect(t, problem.Status, http.StatusGone)
+	expect(t, problem.Detail, "/does/not/exist")
+	expect(t, render.Problem(fmt.Errorf("loading: %w", os.ErrNotExist)).Status, http.StatusBadRequest)
+}
+
+func Test_ErrorAs_WithAnUntypedNil_ShouldPanic(t *testing.T) {
+	defer func() {
+		expect(t, recover(), "render: ErrorAs expects a typed example, like (*os.PathError)(nil), got nil")
+	}()
+	ErrorAs(nil, func(err error) *Problem { return nil })
+	t.Error("Expected a panic.")
+}
+
+func Test_ProblemRender_Problem_ErrorIs_ShouldReturnACopy(t *testing.T) {
+	render := NewProblemRender().AddMappers(ErrorIs(errProblemTest, *NewProblem(http.StatusNotFound)))
+	render.Problem(errProblemTest).SetDetail("changed")
+	expect(t, render.Problem(errProblemTest).Detail, "")
+}
+
+func Test_ProblemRender_Problem_ShouldFindAProblemInTheChain(t *testing.T) {
+	problem := NewProblem(http.StatusTooManyRequests)
+	expect(t, NewProblemRender().Problem(fmt.Errorf("calling: %w", problem)), problem)
+}
+
+func Test_ProblemRender_Problem_WithAnUnknownError_ShouldNotDiscloseIt(t *testing.T) {
+	problem := NewProblemRender().Problem(errors.New("password is 1234"))
+	expect(t, problem.Status, http.StatusInternalServerError)
+	expect(t, problem.Detail, "")
+}
+
+func Test_ProblemRender_Handler_ShouldRenderTheProblem(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewProblemRender().Handler(NewProblem(http.StatusUnsupportedMediaType)).ServeHTTP(recorder, negotiationRequest("text/xml"))
+	expect(t, recorder.Code, http.StatusUnsupportedMediaType)
+	expect(t, strings.Contains(recorder.Body.String(), "<status>415</status>"), true)
+}
+
+func Test_ProblemRender_Respond_WithAnError_ShouldRenderItsProblem(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewProblemRender().Respond(recorder, http.StatusOK, NewProblem(http.StatusNotFound), Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	err = NewProblemRender().Respond(recorder, http.StatusBadGateway, Problem{Title: "Upstream"}, Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusBadGateway)
+	expect(t, recorder.Body.String(), `{"title":"Upstream","status":502}`)
+
+	recorder = httptest.NewRecorder()
+	err = NewProblemRender().Respond(recorder, http.StatusOK, errors.New("boom"), Options{Request: negotiationRequest("application/xml")})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusInternalServerError)
+	expect(t, recorder.Header().Get("Content-Type"), "application/problem+xml; charset=UTF-8")
+}
+
+func Test_ProblemRender_Respond_WithAnotherValue_ShouldFail(t *testing.T) {
+	refute(t, NewProblemRender().Respond(httptest.NewRecorder(), http.StatusOK, "text", Options{}), nil)
+}
@@ -1,16 +1,110 @@
 package render
 
 import (
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"syscall"
+
+	"github.com/deliverous/cocktails/internal/media"
+)
+
+// Phase identifies the step of the rendering that failed.
+type Phase string
+
+const (
+	// EncodePhase is the conversion of the value to bytes: marshalling or template execution.
+	EncodePhase Phase = "encode"
+	// HeaderPhase is the sending of the headers and of the bytes preceding the payload, like a prefix or a JSONP callback.
+	HeaderPhase Phase = "header"
+	// BodyPhase is the sending of the payload.
+	BodyPhase Phase = "body"
+)
+
+// ErrClientDisconnected matches, using errors.Is, the errors due to a client closing the connection during the rendering.
+var ErrClientDisconnected = errors.New("render: client disconnected")
+
+// RenderError is returned by the renderers when the rendering fails. It records the phase that failed.
+type RenderError struct {
+	Phase Phase
+	Err   error
+}
+
+func (err *RenderError) Error() string {
+	return "render: " + string(err.Phase) + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *RenderError) Unwrap() error {
+	return err.Err
+}
+
+// Is reports whether the error is due to a client disconnection when target is ErrClientDisconnected.
+func (err *RenderError) Is(target error) bool {
+	return target == ErrClientDisconnected && isDisconnection(err.Err)
+}
+
+func isDisconnection(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, io.ErrClosedPipe)
+}
+
+// encodeError wraps err in the EncodePhase, unless it already is a RenderError coming from the writer.
+func encodeError(err error) error {
+	if _, ok := err.(*RenderError); ok {
+		return err
+	}
+	return &RenderError{Phase: EncodePhase, Err: err}
+}
+
+// write sends data in a single call. The first leading bytes of data are the ones preceding the payload:
+// if they could not be sent the error is reported in the HeaderPhase, otherwise in the BodyPhase.
+func write(writer io.Writer, leading int, data []byte) error {
+	n, err := writer.Write(data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*RenderError); ok {
+		return err
+	}
+	if n < leading {
+		return &RenderError{Phase: HeaderPhase, Err: err}
+	}
+	return &RenderError{Phase: BodyPhase, Err: err}
+}
+
+// HeaderPolicy defines how a renderer behaves when the Content-Type header is already set on the response.
+// Whatever the policy, the status given to the renderer is always written.
+type HeaderPolicy int
+
+const (
+	// KeepContentType keeps the Content-Type header set before rendering. This is the default.
+	KeepContentType HeaderPolicy = iota
+	// OverrideContentType replaces the Content-Type header set before rendering by the one of the renderer.
+	OverrideContentType
 )
 
-func writeHeader(writer http.ResponseWriter, status int, contentType string, charset string) {
-	if writer.Header().Get("Content-Type") != "" {
-		return
+func writeHeader(writer http.ResponseWriter, status int, contentType string, charset string, policy HeaderPolicy) {
+	if policy == OverrideContentType || writer.Header().Get("Content-Type") == "" {
+		writer.Header().Set("Content-Type", contentTypeWithCharset(contentType, charset))
+	}
+	writer.WriteHeader(status)
+}
+
+// contentTypeWithCharset appends the charset parameter to textual content types only.
+// An empty charset defaults to UTF-8.
+func contentTypeWithCharset(contentType string, charset string) string {
+	if !media.IsTextual(contentType) {
+		return contentType
 	}
 	if charset == "" {
 		charset = "UTF-8"
 	}
-	writer.Header().Set("Content-Type", contentType+"; charset="+charset)
-	writer.WriteHeader(status)
+	return contentType + "; charset=" + charset
 }
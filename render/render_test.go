@@ -0,0 +1,123 @@
+package render
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+)
+
+func Test_ContentTypeWithCharset_ShouldOnlyAppendTheCharsetToTextualTypes(t *testing.T) {
+	expect(t, contentTypeWithCharset("text/plain", "ISO-8859-1"), "text/plain; charset=ISO-8859-1")
+	expect(t, contentTypeWithCharset("text/csv", ""), "text/csv; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/json", ""), "application/json; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/problem+json", ""), "application/problem+json; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/atom+xml", ""), "application/atom+xml; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/javascript", ""), "application/javascript; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/jsonl", ""), "application/jsonl; charset=UTF-8")
+	expect(t, contentTypeWithCharset("application/octet-stream", ""), "application/octet-stream")
+	expect(t, contentTypeWithCharset("image/png", "UTF-8"), "image/png")
+	expect(t, contentTypeWithCharset("application/msgpack", ""), "application/msgpack")
+	expect(t, contentTypeWithCharset("invalid", ""), "invalid")
+}
+
+func Test_RenderError_WhenEncodingFails_ShouldBeInEncodePhase(t *testing.T) {
+	_, err := renderJSON(NewJSONRender(), http.StatusOK, math.NaN())
+	expectPhase(t, err, EncodePhase)
+	_, err = renderTemplate(staticTemplateRender(), http.StatusOK, "unknown", nil)
+	expectPhase(t, err, EncodePhase)
+}
+
+func Test_RenderError_WhenWriteFails_ShouldBeReturnedByEveryRenderer(t *testing.T) {
+	failure := errors.New("failure")
+	expectPhase(t, NewDataRender().Render(newFailingWriter(0, failure), http.StatusOK, []byte("data")), BodyPhase)
+	expectPhase(t, NewJSONRender().Render(newFailingWriter(0, failure), http.StatusOK, 1), BodyPhase)
+	expectPhase(t, NewJSONPRender().Render(newFailingWriter(0, failure), http.StatusOK, "callback", 1), HeaderPhase)
+	expectPhase(t, NewXMLRender().Render(newFailingWriter(0, failure), http.StatusOK, XMLGreeting{}), BodyPhase)
+	expectPhase(t, staticTemplateRender().Render(newFailingWriter(0, failure), http.StatusOK, "main", nil), BodyPhase)
+	expectPhase(t, NewJSONRender().SetStream(true).Render(newFailingWriter(0, failure), http.StatusOK, 1), BodyPhase)
+	expectPhase(t, NewXMLRender().SetStream(true).Render(newFailingWriter(0, failure), http.StatusOK, XMLGreeting{}), BodyPhase)
+	expectPhase(t, NewJSONRender().RenderArray(newFailingWriter(0, failure), http.StatusOK, SliceIterator([]int{1})), BodyPhase)
+	expectPhase(t, NewJSONRender().RenderLines(newFailingWriter(0, failure), http.StatusOK, SliceIterator([]int{1})), BodyPhase)
+}
+
+func Test_RenderError_WhenPrefixCannotBeWritten_ShouldBeInHeaderPhase(t *testing.T) {
+	err := NewJSONRender().SetPrefix([]byte("prefix")).Render(newFailingWriter(3, errors.New("failure")), http.StatusOK, 1)
+	expectPhase(t, err, HeaderPhase)
+	err = NewJSONRender().SetPrefix([]byte("prefix")).Render(newFailingWriter(7, errors.New("failure")), http.StatusOK, 10)
+	expectPhase(t, err, BodyPhase)
+}
+
+func Test_RenderError_WhenShortWrite_ShouldFail(t *testing.T) {
+	err := NewDataRender().Render(newFailingWriter(2, nil), http.StatusOK, []byte("data"))
+	expectPhase(t, err, BodyPhase)
+	expect(t, errors.Is(err, io.ErrShortWrite), true)
+}
+
+func Test_RenderError_WhenClientDisconnects_ShouldMatchErrClientDisconnected(t *testing.T) {
+	err := NewJSONRender().Render(newFailingWriter(0, &net.OpError{Op: "write", Err: syscall.EPIPE}), http.StatusOK, 1)
+	expect(t, errors.Is(err, ErrClientDisconnected), true)
+	err = NewJSONRender().Render(newFailingWriter(0, syscall.ECONNRESET), http.StatusOK, 1)
+	expect(t, errors.Is(err, ErrClientDisconnected), true)
+	err = NewJSONRender().Render(newFailingWriter(0, errors.New("failure")), http.StatusOK, 1)
+	expect(t, errors.Is(err, ErrClientDisconnected), false)
+}
+
+func Test_RenderError_ShouldUnwrapTheCause(t *testing.T) {
+	failure := errors.New("failure")
+	err := NewJSONRender().Render(newFailingWriter(0, failure), http.StatusOK, 1)
+	expect(t, errors.Is(err, failure), true)
+	expect(t, err.Error(), "render: body: failure")
+}
+
+func Test_Render_JSONPAndPrefix_ShouldBeWrittenInASingleWrite(t *testing.T) {
+	writer := newFailingWriter(1000, nil)
+	NewJSONPRender().SetIndent(true).Render(writer, http.StatusOK, "callback", 1)
+	expect(t, writer.writes, 1)
+	expect(t, writer.Body.String(), "/**/callback(1);\n")
+
+	writer = newFailingWriter(1000, nil)
+	NewJSONRender().SetPrefix([]byte("prefix")).Render(writer, http.StatusOK, 1)
+	expect(t, writer.writes, 1)
+
+	writer = newFailingWriter(1000, nil)
+	NewJSONRender().SetStream(true).SetPrefix([]byte("prefix")).Render(writer, http.StatusOK, 1)
+	expect(t, writer.writes, 1)
+	expect(t, writer.Body.String(), "prefix1\n")
+}
+
+func expectPhase(t *testing.T, err error, phase Phase) {
+	var renderError *RenderError
+	if !errors.As(err, &renderError) {
+		t.Errorf("Expected a RenderError, got %#v.", err)
+		return
+	}
+	expect(t, renderError.Phase, phase)
+}
+
+// failingWriter accepts at most limit bytes, then fails with err.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	limit  int
+	err    error
+	writes int
+}
+
+func newFailingWriter(limit int, err error) *failingWriter {
+	return &failingWriter{ResponseRecorder: httptest.NewRecorder(), limit: limit, err: err}
+}
+
+func (writer *failingWriter) Write(b []byte) (int, error) {
+	writer.writes++
+	if len(b) <= writer.limit {
+		writer.limit -= len(b)
+		return writer.ResponseRecorder.Write(b)
+	}
+	n, _ := writer.ResponseRecorder.Write(b[:writer.limit])
+	writer.limit = 0
+	return n, writer.err
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Options holds the per-call parameters that some renderers need in addition to the value to render.
+type Options struct {
+	// Callback is the name of the JSONP callback function.
+	Callback string
+	// Template is the name of the template to execute.
+	Template string
+	// Layout is the layout in which the template is inserted. Default is the layout of the TemplateRender.
+	Layout string
+	// Request is the request being answered, used by the request functions of TemplateRender.
+	Request *http.Request
+}
+
+// Renderer is the common interface implemented by all built-in renderers.
+// It allows to choose a renderer at runtime and to use it without knowing its concrete type.
+type Renderer interface {
+	Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error
+}
+
+// RendererFunc adapts an ordinary function to the Renderer interface.
+type RendererFunc func(writer http.ResponseWriter, status int, v interface{}, options Options) error
+
+// Respond calls function(writer, status, v, options).
+func (function RendererFunc) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return function(writer, status, v, options)
+}
+
+// ValueRenderer is the shape of the renderers that only need the value to render, like JSONRender or XMLRender.
+type ValueRenderer interface {
+	Render(writer http.ResponseWriter, status int, v interface{}) error
+}
+
+// FromValueRenderer adapts a ValueRenderer to the Renderer interface. The options are ignored.
+func FromValueRenderer(render ValueRenderer) Renderer {
+	return RendererFunc(func(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+		return render.Render(writer, status, v)
+	})
+}
+
+// ErrMissingTemplate is returned when a TemplateRender is used through the Renderer interface without template name.
+var ErrMissingTemplate = errors.New("render: missing template name")
+
+var (
+	_ Renderer = (*CSVRender)(nil)
+	_ Renderer = (*DataRender)(nil)
+	_ Renderer = (*JSONRender)(nil)
+	_ Renderer = (*JSONPRender)(nil)
+	_ Renderer = (*NDJSONRender)(nil)
+	_ Renderer = (*ProblemRender)(nil)
+	_ Renderer = (*TemplateRender)(nil)
+	_ Renderer = (*TextRender)(nil)
+	_ Renderer = (*XMLRender)(nil)
+)
+
+// Respond implements the Renderer interface.
+func (render *CSVRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, v)
+}
+
+// Respond implements the Renderer interface. The value must be a []byte or a string.
+func (render *DataRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	switch data := v.(type) {
+	case []byte:
+		return render.Render(writer, status, data)
+	case string:
+		return render.Render(writer, status, []byte(data))
+	case nil:
+		return render.Render(writer, status, nil)
+	default:
+		return fmt.Errorf("render: DataRender cannot render a %T", v)
+	}
+}
+
+// Respond implements the Renderer interface.
+func (render *JSONRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, v)
+}
+
+// Respond implements the Renderer interface. The callback is taken from the options.
+func (render *JSONPRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, options.Callback, v)
+}
+
+// Respond implements the Renderer interface.
+func (render *NDJSONRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, v)
+}
+
+// Respond implements the Renderer interface. The template name, the layout and the request are taken from the options.
+func (render *TemplateRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	if options.Template == "" {
+		return ErrMissingTemplate
+	}
+	layout := options.Layout
+	if layout == "" {
+		layout = render.Layout
+	}
+	return render.render(writer, options.Request, status, layout, options.Template, v)
+}
+
+// Respond implements the Renderer interface. The value is formatted with fmt.Sprint.
+func (render *TextRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, fmt.Sprint(v))
+}
+
+// Respond implements the Renderer interface.
+func (render *XMLRender) Respond(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+	return render.Render(writer, status, v)
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Renderer_Data_ShouldRenderBytes(t *testing.T) {
+	recorder, err := respond(NewDataRender(), http.StatusCreated, []byte("Hello world"), Options{})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Body.String(), `Hello world`)
+}
+
+func Test_Renderer_Data_ShouldRenderString(t *testing.T) {
+	recorder, _ := respond(NewDataRender(), http.StatusOK, "Hello world", Options{})
+	expect(t, recorder.Body.String(), `Hello world`)
+}
+
+func Test_Renderer_Data_WithUnsupportedValue_ShouldFail(t *testing.T) {
+	recorder, err := respond(NewDataRender(), http.StatusOK, 42, Options{})
+	refute(t, err, nil)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Renderer_JSON_ShouldRenderTheValue(t *testing.T) {
+	recorder, _ := respond(NewJSONRender(), http.StatusOK, JSONGreeting{"hello", "world"}, Options{})
+	expect(t, recorder.Body.String(), `{"one":"hello","two":"world"}`)
+}
+
+func Test_Renderer_JSONP_ShouldUseTheCallbackOption(t *testing.T) {
+	recorder, _ := respond(NewJSONPRender(), http.StatusOK, JSONPGreeting{"hello", "world"}, Options{Callback: "cb"})
+	expect(t, recorder.Body.String(), `/**/cb({"one":"hello","two":"world"});`)
+}
+
+func Test_Renderer_XML_ShouldRenderTheValue(t *testing.T) {
+	recorder, _ := respond(NewXMLRender(), http.StatusOK, XMLGreeting{One: "hello", Two: "world"}, Options{})
+	expect(t, recorder.Body.String(), `<greeting one="hello" two="world"></greeting>`)
+}
+
+func Test_Renderer_Template_ShouldUseTheTemplateOption(t *testing.T) {
+	recorder, _ := respond(staticTemplateRender(), http.StatusOK, "World", Options{Template: "hello"})
+	expect(t, recorder.Body.String(), `<h1>Hello World</h1>`)
+}
+
+func Test_Renderer_Template_WithoutTemplateOption_ShouldFail(t *testing.T) {
+	recorder, err := respond(staticTemplateRender(), http.StatusOK, nil, Options{})
+	expect(t, err, ErrMissingTemplate)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Renderer_RendererFunc_ShouldCallTheFunction(t *testing.T) {
+	renderer := RendererFunc(func(writer http.ResponseWriter, status int, v interface{}, options Options) error {
+		writer.WriteHeader(status)
+		writer.Write([]byte(v.(string) + options.Template))
+		return nil
+	})
+	recorder, _ := respond(renderer, http.StatusAccepted, "custom", Options{Template: "-option"})
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Body.String(), `custom-option`)
+}
+
+func Test_Renderer_FromValueRenderer_ShouldAdaptTheRenderer(t *testing.T) {
+	recorder, _ := respond(FromValueRenderer(NewJSONRender()), http.StatusOK, JSONGreeting{"hello", "world"}, Options{})
+	expect(t, recorder.Body.String(), `{"one":"hello","two":"world"}`)
+}
+
+func respond(renderer Renderer, status int, v interface{}, options Options) (*httptest.ResponseRecorder, error) {
+	recorder := httptest.NewRecorder()
+	err := renderer.Respond(recorder, status, v, options)
+	return recorder, err
+}
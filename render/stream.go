@@ -0,0 +1,187 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Iterator returns the next value of a sequence, and false once the sequence is exhausted.
+type Iterator func() (interface{}, bool)
+
+// ChannelIterator creates an Iterator receiving the values from the given channel until it is closed.
+// It panics if channel is not a channel.
+func ChannelIterator(channel interface{}) Iterator {
+	value := reflect.ValueOf(channel)
+	if value.Kind() != reflect.Chan {
+		panic(fmt.Sprintf("render: ChannelIterator expects a channel, got %T", channel))
+	}
+	return func() (interface{}, bool) {
+		item, ok := value.Recv()
+		if !ok {
+			return nil, false
+		}
+		return item.Interface(), true
+	}
+}
+
+// SliceIterator creates an Iterator over the elements of the given slice or array.
+// It panics if slice is neither a slice nor an array.
+func SliceIterator(slice interface{}) Iterator {
+	value := reflect.ValueOf(slice)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		panic(fmt.Sprintf("render: SliceIterator expects a slice, got %T", slice))
+	}
+	index := 0
+	return func() (interface{}, bool) {
+		if index >= value.Len() {
+			return nil, false
+		}
+		index++
+		return value.Index(index - 1).Interface(), true
+	}
+}
+
+// headerWriter delays the call to writeHeader, and the write of the prefix, until the first byte is written,
+// so that a failure happening before any output leaves the response untouched.
+// The prefix is sent with the first bytes in a single write. Write errors are returned as RenderError.
+type headerWriter struct {
+	http.ResponseWriter
+	status      int
+	contentType string
+	charset     string
+	policy      HeaderPolicy
+	prefix      []byte
+	wroteHeader bool
+}
+
+func newHeaderWriter(writer http.ResponseWriter, status int, contentType string, charset string, policy HeaderPolicy, prefix []byte) *headerWriter {
+	return &headerWriter{
+		ResponseWriter: writer,
+		status:         status,
+		contentType:    contentType,
+		charset:        charset,
+		policy:         policy,
+		prefix:         prefix,
+	}
+}
+
+func (writer *headerWriter) Write(b []byte) (int, error) {
+	if writer.wroteHeader {
+		if err := write(writer.ResponseWriter, 0, b); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	writer.wroteHeader = true
+	writeHeader(writer.ResponseWriter, writer.status, writer.contentType, writer.charset, writer.policy)
+	if err := write(writer.ResponseWriter, len(writer.prefix), concat(writer.prefix, b, false)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeHeader sends the header and the prefix if nothing has been written yet.
+func (writer *headerWriter) writeHeader() error {
+	if writer.wroteHeader {
+		return nil
+	}
+	if len(writer.prefix) == 0 {
+		writer.wroteHeader = true
+		writeHeader(writer.ResponseWriter, writer.status, writer.contentType, writer.charset, writer.policy)
+		return nil
+	}
+	_, err := writer.Write(nil)
+	return err
+}
+
+// Flush sends the header if needed and flushes the response.
+func (writer *headerWriter) Flush() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// RenderArray streams the values returned by next as a JSON array, without buffering the whole array.
+// The response is flushed every FlushEvery elements and at the end.
+// If the first element cannot be encoded, nothing is written; later encoding errors are returned
+// after the elements already encoded have been sent.
+func (render *JSONRender) RenderArray(writer http.ResponseWriter, status int, next Iterator) error {
+	output := newHeaderWriter(writer, status, render.ContentType, render.Charset, render.HeaderPolicy, render.Prefix)
+	opening, separator, closing := "[", ",", "]"
+	if render.Indent {
+		opening, separator, closing = "[\n  ", ",\n  ", "\n]\n"
+	}
+
+	count := 0
+	for value, ok := next(); ok; value, ok = next() {
+		var result []byte
+		var err error
+		if render.Indent {
+			result, err = json.MarshalIndent(value, "  ", "  ")
+		} else {
+			result, err = json.Marshal(value)
+		}
+		if err != nil {
+			return encodeError(fmt.Errorf("element %d: %v", count, err))
+		}
+		if count == 0 {
+			result = append([]byte(opening), result...)
+		} else {
+			result = append([]byte(separator), result...)
+		}
+		if _, err := output.Write(result); err != nil {
+			return err
+		}
+		count++
+		if err := render.flushEvery(output, count); err != nil {
+			return err
+		}
+	}
+
+	if count == 0 {
+		closing = "[]"
+		if render.Indent {
+			closing = "[]\n"
+		}
+	}
+	if _, err := output.Write([]byte(closing)); err != nil {
+		return err
+	}
+	return output.Flush()
+}
+
+// RenderLines streams the values returned by next as newline delimited JSON, one value per line.
+// Use SetContentType("application/x-ndjson") to advertise the format.
+// The response is flushed every FlushEvery elements and at the end.
+func (render *JSONRender) RenderLines(writer http.ResponseWriter, status int, next Iterator) error {
+	output := newHeaderWriter(writer, status, render.ContentType, render.Charset, render.HeaderPolicy, render.Prefix)
+	encoder := json.NewEncoder(output)
+
+	count := 0
+	for value, ok := next(); ok; value, ok = next() {
+		if err := encoder.Encode(value); err != nil {
+			if _, ok := err.(*RenderError); ok {
+				return err
+			}
+			return encodeError(fmt.Errorf("element %d: %v", count, err))
+		}
+		count++
+		if err := render.flushEvery(output, count); err != nil {
+			return err
+		}
+	}
+	return output.Flush()
+}
+
+func (render *JSONRender) flushEvery(writer *headerWriter, count int) error {
+	if render.FlushEvery <= 1 || count%render.FlushEvery == 0 {
+		return writer.Flush()
+	}
+	return nil
+}
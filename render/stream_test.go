@@ -0,0 +1,131 @@
+package render
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Stream_JSON_Render_ShouldEncodeTheValue(t *testing.T) {
+	recorder, err := renderJSON(NewJSONRender().SetStream(true), http.StatusCreated, JSONGreeting{"hello", "world"})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+	expect(t, recorder.Body.String(), "{\"one\":\"hello\",\"two\":\"world\"}\n")
+}
+
+func Test_Stream_JSON_Render_WithIndentAndPrefix_ShouldBeOK(t *testing.T) {
+	recorder, _ := renderJSON(NewJSONRender().SetStream(true).SetIndent(true).SetPrefix([]byte("prefix")), http.StatusOK, JSONGreeting{"hello", "world"})
+	expect(t, recorder.Body.String(), "prefix{\n  \"one\": \"hello\",\n  \"two\": \"world\"\n}\n")
+}
+
+func Test_Stream_JSON_Render_WithInvalidData_ShouldFailWithoutWrittingAnyResponse(t *testing.T) {
+	recorder, err := renderJSON(NewJSONRender().SetStream(true).SetPrefix([]byte("prefix")), http.StatusCreated, math.NaN)
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, recorder.Body.String(), ``)
+	refute(t, err, nil)
+}
+
+func Test_Stream_XML_Render_ShouldEncodeTheValue(t *testing.T) {
+	recorder, err := renderXML(NewXMLRender().SetStream(true), http.StatusCreated, XMLGreeting{One: "hello", Two: "world"})
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Body.String(), `<greeting one="hello" two="world"></greeting>`)
+}
+
+func Test_Stream_XML_Render_WithIndentAndPrefix_ShouldBeOK(t *testing.T) {
+	recorder, _ := renderXML(NewXMLRender().SetStream(true).SetIndent(true).SetPrefix([]byte("prefix")), http.StatusOK, XMLGreeting{One: "hello", Two: "world"})
+	expect(t, recorder.Body.String(), "prefix<greeting one=\"hello\" two=\"world\"></greeting>\n")
+}
+
+func Test_Stream_XML_Render_WithNilData_ShouldOnlyWriteTheHeader(t *testing.T) {
+	recorder, _ := renderXML(NewXMLRender().SetStream(true), http.StatusAccepted, nil)
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Stream_XML_Render_WithInvalidData_ShouldFail(t *testing.T) {
+	_, err := renderXML(NewXMLRender().SetStream(true), http.StatusCreated, math.NaN)
+	refute(t, err, nil)
+}
+
+func Test_Stream_RenderArray_ShouldRenderAJSONArray(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewJSONRender().RenderArray(recorder, http.StatusCreated, SliceIterator([]JSONGreeting{{"a", "b"}, {"c", "d"}}))
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Body.String(), `[{"one":"a","two":"b"},{"one":"c","two":"d"}]`)
+	expect(t, recorder.Flushed, true)
+}
+
+func Test_Stream_RenderArray_WithIndent_ShouldBeOK(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewJSONRender().SetIndent(true).RenderArray(recorder, http.StatusOK, SliceIterator([]int{1, 2}))
+	expect(t, recorder.Body.String(), "[\n  1,\n  2\n]\n")
+}
+
+func Test_Stream_RenderArray_WithEmptySequence_ShouldRenderAnEmptyArray(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewJSONRender().SetPrefix([]byte("prefix")).RenderArray(recorder, http.StatusOK, SliceIterator([]int{}))
+	expect(t, recorder.Body.String(), `prefix[]`)
+}
+
+func Test_Stream_RenderArray_WithChannel_ShouldRenderAllValues(t *testing.T) {
+	channel := make(chan string, 3)
+	channel <- "a"
+	channel <- "b"
+	channel <- "c"
+	close(channel)
+	recorder := httptest.NewRecorder()
+	NewJSONRender().RenderArray(recorder, http.StatusOK, ChannelIterator(channel))
+	expect(t, recorder.Body.String(), `["a","b","c"]`)
+}
+
+func Test_Stream_RenderArray_WithInvalidFirstElement_ShouldFailWithoutWrittingAnyResponse(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewJSONRender().RenderArray(recorder, http.StatusCreated, SliceIterator([]interface{}{math.NaN, 1}))
+	refute(t, err, nil)
+	expect(t, recorder.Code, http.StatusOK)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Stream_RenderArray_WithInvalidElement_ShouldReturnTheError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewJSONRender().RenderArray(recorder, http.StatusCreated, SliceIterator([]interface{}{1, math.NaN}))
+	refute(t, err, nil)
+	expect(t, recorder.Code, http.StatusCreated)
+	expect(t, recorder.Body.String(), `[1`)
+}
+
+func Test_Stream_RenderLines_ShouldRenderOneValuePerLine(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewJSONRender().SetContentType("application/x-ndjson").RenderLines(recorder, http.StatusOK, SliceIterator([]JSONGreeting{{"a", "b"}, {"c", "d"}}))
+	expect(t, err, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "application/x-ndjson; charset=UTF-8")
+	expect(t, recorder.Body.String(), "{\"one\":\"a\",\"two\":\"b\"}\n{\"one\":\"c\",\"two\":\"d\"}\n")
+}
+
+func Test_Stream_RenderLines_WithEmptySequence_ShouldWriteTheHeader(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewJSONRender().RenderLines(recorder, http.StatusAccepted, SliceIterator([]int{}))
+	expect(t, recorder.Code, http.StatusAccepted)
+	expect(t, recorder.Body.String(), ``)
+}
+
+func Test_Stream_FlushEvery_ShouldFlushPeriodically(t *testing.T) {
+	writer := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	NewJSONRender().SetFlushEvery(2).RenderLines(writer, http.StatusOK, SliceIterator([]int{1, 2, 3, 4, 5}))
+	// Two periodic flushes, and the final one.
+	expect(t, writer.count, 3)
+}
+
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	count int
+}
+
+func (writer *flushCounter) Flush() {
+	writer.count++
+	writer.ResponseRecorder.Flush()
+}
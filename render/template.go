@@ -2,22 +2,50 @@ package render
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
-	"io/ioutil"
+	"io/fs"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // HTML built-in renderer.
 type TemplateRender struct {
 	ContentType string
 	Charset     string
-	Factory     TemplateFactory
+	// HeaderPolicy defines what to do when the Content-Type header is already set. Default is KeepContentType.
+	HeaderPolicy HeaderPolicy
+	Factory      TemplateFactory
 	// If IsDevelopment is set to true, this will recompile the templates on every request. Default if false.
 	IsDevelopment bool
+	// Layout is the template in which the rendered templates are inserted with {{ yield }}. Default is no layout.
+	Layout string
+	// RequestFunctions provide the template functions depending on the request, like the translation functions.
+	RequestFunctions []RequestFunctions
+
+	// compiled holds the current *compiledTemplates. It is swapped atomically, so that concurrent renderings
+	// always use a complete set of templates.
+	compiled atomic.Value
+	// compiling serializes the first compilation.
+	compiling sync.Mutex
+	// watching is set by Watch once the templates are compiled, as renderings may be running concurrently.
+	watching atomic.Bool
+}
+
+// RequestFunctions returns the template functions for the rendering of a response to the request.
+// The functions must also be declared in the TemplateOptions of the factory, usually with a default
+// implementation, as the templates are parsed before any request.
+// It is called for every rendering, with a nil request when the template is rendered without request.
+type RequestFunctions func(request *http.Request) template.FuncMap
 
-	templates *template.Template
+// compiledTemplates is the result of a compilation of the templates.
+type compiledTemplates struct {
+	templates *templateSet
+	err       error
 }
 
 // NewXMLRender creates a new JSON render with default values.
@@ -29,6 +57,16 @@ func NewTemplateRender() *TemplateRender {
 	}
 }
 
+// NewCompiledTemplateRender creates a new template render with default values, and compiles its templates
+// immediately so that template errors are reported at startup rather than on the first request.
+func NewCompiledTemplateRender(factory TemplateFactory) (*TemplateRender, error) {
+	render := NewTemplateRender().SetFactory(factory)
+	if err := render.CompileTemplates(); err != nil {
+		return nil, err
+	}
+	return render, nil
+}
+
 func (render *TemplateRender) SetContentType(value string) *TemplateRender {
 	render.ContentType = value
 	return render
@@ -39,38 +77,144 @@ func (render *TemplateRender) SetCharset(value string) *TemplateRender {
 	return render
 }
 
+func (render *TemplateRender) SetHeaderPolicy(value HeaderPolicy) *TemplateRender {
+	render.HeaderPolicy = value
+	return render
+}
+
 func (render *TemplateRender) SetFactory(value TemplateFactory) *TemplateRender {
 	render.Factory = value
 	return render
 }
 
+func (render *TemplateRender) SetLayout(value string) *TemplateRender {
+	render.Layout = value
+	return render
+}
+
+func (render *TemplateRender) SetRequestFunctions(functions ...RequestFunctions) *TemplateRender {
+	render.RequestFunctions = functions
+	return render
+}
+
+// CompileTemplates compiles the templates if they have not been successfully compiled yet, or on every call
+// in development. It is safe to call it concurrently: only one goroutine compiles the templates the first time.
 func (render *TemplateRender) CompileTemplates() error {
-	if render.IsDevelopment || render.templates == nil {
-		if tmpl, err := render.Factory.Create(); err != nil {
-			return err
-		} else {
-			render.templates = tmpl
-		}
+	return render.templates().err
+}
+
+// templates returns the templates to use for a rendering, compiling them if needed.
+func (render *TemplateRender) templates() *compiledTemplates {
+	if render.IsDevelopment {
+		return render.compile()
+	}
+	if compiled := render.current(); compiled != nil && compiled.err == nil {
+		return compiled
+	}
+
+	render.compiling.Lock()
+	defer render.compiling.Unlock()
+	if compiled := render.current(); compiled != nil && compiled.err == nil {
+		return compiled
+	}
+	return render.compile()
+}
+
+// compile creates the templates with the factory and makes them the current ones.
+func (render *TemplateRender) compile() *compiledTemplates {
+	compiled := &compiledTemplates{}
+	if tmpl, err := render.Factory.Create(); err != nil {
+		compiled.err = err
+	} else {
+		compiled.templates = newTemplateSet(tmpl)
 	}
-	return nil
+	render.compiled.Store(compiled)
+	return compiled
 }
 
-// Render a template response.
+func (render *TemplateRender) current() *compiledTemplates {
+	compiled, _ := render.compiled.Load().(*compiledTemplates)
+	return compiled
+}
+
+// Watch compiles the templates, then compiles them again each time the watcher reports a change, instead of
+// compiling them on the first request or on every request in development.
+// While the templates do not compile, the renderings respond with an HTML page describing the error, and
+// return no error as the response is complete. The compilation stops when the watcher is closed.
+func (render *TemplateRender) Watch(watcher TemplateWatcher) *TemplateRender {
+	render.compile()
+	render.watching.Store(true)
+	go func() {
+		for range watcher.Changes() {
+			render.compile()
+		}
+	}()
+	return render
+}
+
+// Render a template response, inside the default layout if any. There is no request, so the templates calling
+// {{ cspNonce }} fail: use RenderRequest for the pages protected by a Content-Security-Policy.
 func (render *TemplateRender) Render(writer http.ResponseWriter, status int, name string, binding interface{}) error {
-	if err := render.CompileTemplates(); err != nil {
-		return err
+	return render.RenderLayout(writer, status, render.Layout, name, binding)
+}
+
+// RenderLayout renders a template response inside the given layout. An empty layout renders the template alone.
+//
+// The layout inserts the template with {{ yield }}. In the layout and in the template, {{ current }} returns
+// the name of the template, and {{ partial "name" }} renders "name-<template>" if it exists, "name" otherwise.
+// The templates defined by the template, with define or block, take precedence over the ones defined by the layout.
+// As with Render, the templates calling {{ cspNonce }} fail.
+func (render *TemplateRender) RenderLayout(writer http.ResponseWriter, status int, layout string, name string, binding interface{}) error {
+	return render.render(writer, nil, status, layout, name, binding)
+}
+
+// RenderRequest renders a template response to the request, inside the default layout if any.
+// The request functions are given the request, see RequestFunctions, and {{ cspNonce }} returns its
+// Content-Security-Policy nonce, see WithCSPNonce, empty if it has none.
+func (render *TemplateRender) RenderRequest(writer http.ResponseWriter, request *http.Request, status int, name string, binding interface{}) error {
+	return render.render(writer, request, status, render.Layout, name, binding)
+}
+
+func (render *TemplateRender) render(writer http.ResponseWriter, request *http.Request, status int, layout string, name string, binding interface{}) error {
+	var compiled *compiledTemplates
+	if render.watching.Load() {
+		if compiled = render.current(); compiled.err != nil {
+			return renderCompilationError(writer, compiled.err)
+		}
+	} else if compiled = render.templates(); compiled.err != nil {
+		return compiled.err
 	}
 
 	out := new(bytes.Buffer)
-	if err := render.templates.ExecuteTemplate(out, name, binding); err != nil {
-		return err
+	if err := compiled.templates.execute(out, layout, name, binding, render.functions(request)); err != nil {
+		return encodeError(err)
 	}
 
-	writeHeader(writer, status, render.ContentType, render.Charset)
-	writer.Write(out.Bytes())
-	return nil
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, 0, out.Bytes())
+}
+
+// functions returns the functions depending on the request: cspNonce, and the request functions.
+func (render *TemplateRender) functions(request *http.Request) template.FuncMap {
+	nonce := CSPNonce(request)
+	functions := template.FuncMap{
+		"cspNonce": func() (string, error) {
+			if request == nil {
+				return "", errCSPNonceWithoutRequest
+			}
+			return nonce, nil
+		},
+	}
+	for _, provider := range render.RequestFunctions {
+		for name, function := range provider(request) {
+			functions[name] = function
+		}
+	}
+	return functions
 }
 
+var errCSPNonceWithoutRequest = errors.New("render: cspNonce needs a request, use RenderRequest")
+
 type TemplateOptions struct {
 	// Left delimiter, defaults to {{.
 	LeftDelimiter string
@@ -122,33 +266,105 @@ func (factory *DiskTemplateFactory) SetFunctions(functions ...template.FuncMap)
 }
 
 func (factory *DiskTemplateFactory) Create() (*template.Template, error) {
-	result := template.New(factory.Directory)
-	result.Delims(factory.Options.LeftDelimiter, factory.Options.RightDelimiter)
+	return factory.fsFactory().Create()
+}
+
+func (factory *DiskTemplateFactory) fsFactory() *FSTemplateFactory {
+	return &FSTemplateFactory{
+		Options:    factory.Options,
+		FileSystem: os.DirFS(factory.Directory),
+		Directory:  ".",
+		Extensions: factory.Extensions,
+		Name:       factory.Directory,
+	}
+}
+
+// FSTemplateFactory loads the templates from a file system, like an embed.FS.
+type FSTemplateFactory struct {
+	// Options of templates
+	Options TemplateOptions
+	// File system to load templates from.
+	FileSystem fs.FS
+	// Directory of the file system to load templates. Default is ".".
+	Directory string
+	// Extensions to parse template files from. Defaults to [".tmpl"].
+	Extensions []string
+	// Name of the root template. Default is the directory.
+	Name string
+}
+
+func NewFSTemplateFactory(fileSystem fs.FS) *FSTemplateFactory {
+	return &FSTemplateFactory{
+		FileSystem: fileSystem,
+		Directory:  ".",
+		Extensions: []string{".tmpl"},
+	}
+}
 
-	return result, filepath.Walk(factory.Directory, func(path string, info os.FileInfo, err error) error {
-		relative, err := filepath.Rel(factory.Directory, path)
+func (factory *FSTemplateFactory) SetDirectory(value string) *FSTemplateFactory {
+	factory.Directory = value
+	return factory
+}
+
+func (factory *FSTemplateFactory) SetExtensions(values ...string) *FSTemplateFactory {
+	factory.Extensions = values
+	return factory
+}
+
+func (factory *FSTemplateFactory) SetDelimiters(left string, right string) *FSTemplateFactory {
+	factory.Options.LeftDelimiter = left
+	factory.Options.RightDelimiter = right
+	return factory
+}
+
+func (factory *FSTemplateFactory) SetFunctions(functions ...template.FuncMap) *FSTemplateFactory {
+	factory.Options.Functions = functions
+	return factory
+}
+
+// Create parses all the files of the directory having one of the extensions. Each template is named after
+// the path of its file relative to the directory, using slashes and without the extension.
+func (factory *FSTemplateFactory) Create() (*template.Template, error) {
+	result := createTemplates(factory.rootName(), factory.Options)
+	err := factory.walk(func(name string, file string, content []byte) error {
+		return parseTemplate(result, name, string(content), factory.Options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (factory *FSTemplateFactory) rootName() string {
+	if factory.Name == "" {
+		return factory.Directory
+	}
+	return factory.Name
+}
+
+// walk calls visit with the template name, the path and the content of each template file.
+func (factory *FSTemplateFactory) walk(visit func(name string, file string, content []byte) error) error {
+	prefix := factory.Directory + "/"
+	if factory.Directory == "." {
+		prefix = ""
+	}
+
+	return fs.WalkDir(factory.FileSystem, factory.Directory, func(file string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if entry.IsDir() {
+			return nil
+		}
 
-		ext := filepath.Ext(relative)
-
+		ext := path.Ext(file)
 		for _, extension := range factory.Extensions {
 			if ext == extension {
-				buf, err := ioutil.ReadFile(path)
+				content, err := fs.ReadFile(factory.FileSystem, file)
 				if err != nil {
 					return err
 				}
-
-				tmpl := result.New(filepath.ToSlash((relative[0 : len(relative)-len(ext)])))
-				for _, funcs := range factory.Options.Functions {
-					tmpl.Funcs(funcs)
-				}
-
-				if _, err := tmpl.Parse(string(buf)); err != nil {
-					return err
-				}
-				break
+				return visit(strings.TrimPrefix(file[0:len(file)-len(ext)], prefix), file, content)
 			}
 		}
 		return nil
@@ -189,17 +405,11 @@ func (factory *StaticTemplateFactory) SetFunctions(functions ...template.FuncMap
 }
 
 func (factory *StaticTemplateFactory) Create() (*template.Template, error) {
-	result := template.New(factory.Name)
-	result.Delims(factory.Options.LeftDelimiter, factory.Options.RightDelimiter)
+	result := createTemplates(factory.Name, factory.Options)
 
 	for _, sub := range factory.SubTemplates {
 		name, content := sub()
-		tmpl := result.New(name)
-		for _, funcs := range factory.Options.Functions {
-			tmpl.Funcs(funcs)
-		}
-
-		if _, err := tmpl.Parse(content); err != nil {
+		if err := parseTemplate(result, name, content, factory.Options); err != nil {
 			return nil, err
 		}
 	}
@@ -4,8 +4,13 @@ import (
 	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 )
 
 func Test_Template_Render_ShouldSetTheStatus(t *testing.T) {
@@ -20,6 +25,20 @@ func Test_Template_Render_WhenContentTypeAlreadySet_ShouldDoNothing(t *testing.T
 	expect(t, recorder.Header().Get("Content-Type"), "bla")
 }
 
+func Test_Template_Render_WhenContentTypeAlreadySet_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	staticTemplateRender().Render(recorder, http.StatusNotFound, "main", nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+}
+
+func Test_Template_Render_WhenContentTypeAlreadySetWithOverridePolicy_ShouldReplaceTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	staticTemplateRender().SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, "main", nil)
+	expect(t, recorder.Header().Get("Content-Type"), "text/html; charset=UTF-8")
+}
+
 func Test_Template_Render_WhenNoContentType_ShouldSetTheContentType(t *testing.T) {
 	recorder, _ := renderTemplate(staticTemplateRender(), http.StatusOK, "main", nil)
 	expect(t, recorder.Header().Get("Content-Type"), "text/html; charset=UTF-8")
@@ -87,6 +106,66 @@ func Test_Template_Render_InvalidTemplate_ShouldNotRender(t *testing.T) {
 	refute(t, err, nil)
 }
 
+func Test_FSTemplate_Create_ShouldNameTemplatesAfterTheirPath(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewFSTemplateFactory(templateFS()))
+	recorder, err := renderTemplate(render, http.StatusOK, "hello", "World")
+	expect(t, err, nil)
+	expect(t, recorder.Body.String(), `<h1>Hello World</h1>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "admin/users", nil)
+	expect(t, recorder.Body.String(), `<h1>Users</h1>`)
+}
+
+func Test_FSTemplate_Create_ShouldOnlyParseTheExtensions(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewFSTemplateFactory(templateFS()))
+	_, err := renderTemplate(render, http.StatusOK, "readme", nil)
+	refute(t, err, nil)
+
+	render = NewTemplateRender().SetFactory(NewFSTemplateFactory(templateFS()).SetExtensions(".tmpl", ".txt"))
+	recorder, _ := renderTemplate(render, http.StatusOK, "readme", nil)
+	expect(t, recorder.Body.String(), `Read me`)
+}
+
+func Test_FSTemplate_Create_WithDirectory_ShouldNameTemplatesRelativelyToTheDirectory(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewFSTemplateFactory(templateFS()).SetDirectory("admin"))
+	recorder, _ := renderTemplate(render, http.StatusOK, "users", nil)
+	expect(t, recorder.Body.String(), `<h1>Users</h1>`)
+	_, err := renderTemplate(render, http.StatusOK, "hello", nil)
+	refute(t, err, nil)
+}
+
+func Test_FSTemplate_Create_WithDelimitersAndFunctions_ShouldBeOK(t *testing.T) {
+	factory := NewFSTemplateFactory(fstest.MapFS{
+		"title.tmpl": {Data: []byte(`<h1>[[. | upper]]</h1>`)},
+	}).SetDelimiters("[[", "]]").SetFunctions(template.FuncMap{"upper": strings.ToUpper})
+	recorder, _ := renderTemplate(NewTemplateRender().SetFactory(factory), http.StatusOK, "title", "hello world")
+	expect(t, recorder.Body.String(), `<h1>HELLO WORLD</h1>`)
+}
+
+func Test_FSTemplate_Create_WithUnknownDirectory_ShouldFail(t *testing.T) {
+	_, err := NewFSTemplateFactory(templateFS()).SetDirectory("unknown").Create()
+	refute(t, err, nil)
+}
+
+func Test_FSTemplate_Create_WithInvalidTemplate_ShouldFail(t *testing.T) {
+	_, err := NewFSTemplateFactory(fstest.MapFS{"invalid.tmpl": {Data: []byte(`{{.}`)}}).Create()
+	refute(t, err, nil)
+}
+
+func Test_DiskTemplate_Create_ShouldBehaveAsFSTemplate(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{
+		"hello.tmpl":       `<h1>Hello {{.}}</h1>`,
+		"admin/users.tmpl": `<h1>Users</h1>`,
+		"readme.txt":       `Read me`,
+	})
+	render := NewTemplateRender().SetFactory(NewDiskTemplateFactory().SetDirectory(directory))
+	recorder, _ := renderTemplate(render, http.StatusOK, "hello", "World")
+	expect(t, recorder.Body.String(), `<h1>Hello World</h1>`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "admin/users", nil)
+	expect(t, recorder.Body.String(), `<h1>Users</h1>`)
+	_, err := renderTemplate(render, http.StatusOK, "readme", nil)
+	refute(t, err, nil)
+}
+
 func staticTemplateRender() *TemplateRender {
 	return NewTemplateRender().SetFactory(NewStaticTemplateFactory("static").SetSubTemplates(main_template, hello_template))
 }
@@ -108,3 +187,130 @@ func renderTemplate(render *TemplateRender, status int, name string, v interface
 	err := render.Render(recorder, status, name, v)
 	return recorder, err
 }
+
+func templateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"hello.tmpl":       {Data: []byte(`<h1>Hello {{.}}</h1>`)},
+		"admin/users.tmpl": {Data: []byte(`<h1>Users</h1>`)},
+		"readme.txt":       {Data: []byte(`Read me`)},
+	}
+}
+
+func Test_Template_NewCompiledTemplateRender_ShouldCompileTheTemplates(t *testing.T) {
+	render, err := NewCompiledTemplateRender(NewStaticTemplateFactory("static").SetSubTemplates(main_template))
+	expect(t, err, nil)
+	recorder, _ := renderTemplate(render, http.StatusOK, "main", nil)
+	expect(t, recorder.Body.String(), `<h1>Main</h1>`)
+}
+
+func Test_Template_NewCompiledTemplateRender_WithInvalidTemplate_ShouldFail(t *testing.T) {
+	render, err := NewCompiledTemplateRender(NewStaticTemplateFactory("static").SetSubTemplates(invalid_template))
+	refute(t, err, nil)
+	expect(t, render, (*TemplateRender)(nil))
+}
+
+func Test_Template_CompileTemplates_ShouldCompileOnlyOnce(t *testing.T) {
+	factory := &countingFactory{TemplateFactory: NewStaticTemplateFactory("static").SetSubTemplates(main_template)}
+	render := NewTemplateRender().SetFactory(factory)
+	renderConcurrently(t, render, 20, 10)
+	expect(t, atomic.LoadInt32(&factory.count), int32(1))
+}
+
+func Test_Template_CompileTemplates_InDevelopment_ShouldCompileOnEveryRendering(t *testing.T) {
+	factory := &countingFactory{TemplateFactory: NewStaticTemplateFactory("static").SetSubTemplates(main_template)}
+	render := NewTemplateRender().SetFactory(factory)
+	render.IsDevelopment = true
+	renderConcurrently(t, render, 20, 10)
+	expect(t, atomic.LoadInt32(&factory.count), int32(200))
+}
+
+func Test_Template_Render_WithLayoutConcurrently_ShouldBeOK(t *testing.T) {
+	renderConcurrently(t, layoutTemplateRender().SetLayout("layout"), 20, 50)
+}
+
+func renderConcurrently(t *testing.T, render *TemplateRender, goroutines int, renderings int) {
+	var group sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < renderings; j++ {
+				recorder := httptest.NewRecorder()
+				if err := render.Render(recorder, http.StatusOK, "main", nil); err != nil {
+					t.Error(err)
+					return
+				}
+				if !strings.Contains(recorder.Body.String(), `<h1>Main</h1>`) {
+					t.Errorf("Unexpected body %#v", recorder.Body.String())
+					return
+				}
+			}
+		}()
+	}
+	group.Wait()
+}
+
+type countingFactory struct {
+	TemplateFactory
+	count int32
+}
+
+func (factory *countingFactory) Create() (*template.Template, error) {
+	atomic.AddInt32(&factory.count, 1)
+	return factory.TemplateFactory.Create()
+}
+
+func requestTemplateRender() *TemplateRender {
+	factory := NewStaticTemplateFactory("static").
+		SetFunctions(template.FuncMap{"user": func() string { return "nobody" }}).
+		SetSubTemplates(func() (string, string) { return "user", `<p>{{ user }}</p>` })
+	return NewTemplateRender().SetFactory(factory).SetRequestFunctions(func(request *http.Request) template.FuncMap {
+		if request == nil {
+			return template.FuncMap{"user": func() string { return "nobody" }}
+		}
+		user := request.URL.Query().Get("user")
+		return template.FuncMap{"user": func() string { return user }}
+	})
+}
+
+func Test_Template_RenderRequest_ShouldUseTheRequestFunctions(t *testing.T) {
+	render := requestTemplateRender()
+	for _, user := range []string{"alice", "<bob>"} {
+		recorder := httptest.NewRecorder()
+		err := render.RenderRequest(recorder, httptest.NewRequest("GET", "/?user="+url.QueryEscape(user), nil), http.StatusOK, "user", nil)
+		expect(t, err, nil)
+		expect(t, recorder.Body.String(), "<p>"+template.HTMLEscapeString(user)+"</p>")
+	}
+}
+
+func Test_Template_Render_WithRequestFunctions_ShouldCallThemWithoutRequest(t *testing.T) {
+	render := requestTemplateRender()
+	render.RenderRequest(httptest.NewRecorder(), httptest.NewRequest("GET", "/?user=alice", nil), http.StatusOK, "user", nil)
+	recorder, _ := renderTemplate(render, http.StatusOK, "user", nil)
+	expect(t, recorder.Body.String(), "<p>nobody</p>")
+}
+
+func Test_Template_Respond_ShouldPassTheRequestToTheRequestFunctions(t *testing.T) {
+	request := httptest.NewRequest("GET", "/?user=alice", nil)
+	recorder, _ := respond(requestTemplateRender(), http.StatusOK, nil, Options{Template: "user", Request: request})
+	expect(t, recorder.Body.String(), "<p>alice</p>")
+}
+
+func Test_Template_RenderRequest_Concurrently_ShouldUseTheFunctionsOfEachRequest(t *testing.T) {
+	render := requestTemplateRender()
+	var group sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		group.Add(1)
+		go func(user string) {
+			defer group.Done()
+			for j := 0; j < 20; j++ {
+				recorder := httptest.NewRecorder()
+				render.RenderRequest(recorder, httptest.NewRequest("GET", "/?user="+user, nil), http.StatusOK, "user", nil)
+				if recorder.Body.String() != "<p>"+user+"</p>" {
+					t.Errorf("Expected user %s, got %s", user, recorder.Body.String())
+				}
+			}
+		}(strconv.Itoa(i))
+	}
+	group.Wait()
+}
@@ -0,0 +1,47 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Text built-in renderer.
+type TextRender struct {
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+}
+
+func (render *TextRender) SetContentType(value string) *TextRender {
+	render.ContentType = value
+	return render
+}
+
+func (render *TextRender) SetCharset(value string) *TextRender {
+	render.Charset = value
+	return render
+}
+
+func (render *TextRender) SetHeaderPolicy(value HeaderPolicy) *TextRender {
+	render.HeaderPolicy = value
+	return render
+}
+
+// NewTextRender creates a new text render with default values.
+func NewTextRender() *TextRender {
+	return &TextRender{
+		ContentType: "text/plain",
+		Charset:     "UTF-8",
+	}
+}
+
+// Render a text response.
+func (render *TextRender) Render(writer http.ResponseWriter, status int, text string) error {
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, 0, []byte(text))
+}
+
+// Renderf renders a text response formatted like fmt.Sprintf.
+func (render *TextRender) Renderf(writer http.ResponseWriter, status int, format string, args ...interface{}) error {
+	return render.Render(writer, status, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,46 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Text_Render_ShouldSetTheStatusAndTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	err := NewTextRender().Render(recorder, http.StatusNotFound, "not found")
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+	expect(t, recorder.Header().Get("Content-Type"), "text/plain; charset=UTF-8")
+	expect(t, recorder.Body.String(), "not found")
+}
+
+func Test_Text_Renderf_ShouldFormatTheArguments(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewTextRender().Renderf(recorder, http.StatusOK, "%d items, 100%% %s", 3, "done")
+	expect(t, recorder.Body.String(), "3 items, 100% done")
+}
+
+func Test_Text_Render_ShouldWriteTheTextAsIs(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewTextRender().Render(recorder, http.StatusOK, "100%")
+	expect(t, recorder.Body.String(), "100%")
+}
+
+func Test_Text_Render_WhenContentTypeAlreadySet_ShouldFollowTheHeaderPolicy(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewTextRender().SetCharset("ISO-8859-1").Render(recorder, http.StatusCreated, "text")
+	expect(t, recorder.Header().Get("Content-Type"), "bla")
+	expect(t, recorder.Code, http.StatusCreated)
+
+	recorder = httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewTextRender().SetContentType("text/markdown").SetCharset("ISO-8859-1").SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, "text")
+	expect(t, recorder.Header().Get("Content-Type"), "text/markdown; charset=ISO-8859-1")
+}
+
+func Test_Renderer_Text_ShouldFormatTheValue(t *testing.T) {
+	recorder, _ := respond(NewTextRender(), http.StatusOK, 42, Options{})
+	expect(t, recorder.Body.String(), "42")
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TemplateProblem is an error found in a template file.
+type TemplateProblem struct {
+	// File is the path of the template file in the file system, empty when unknown.
+	File string
+	// Line is the line of the error in the file, 0 when unknown.
+	Line    int
+	Message string
+}
+
+func (problem TemplateProblem) String() string {
+	location := problem.File
+	if location == "" {
+		location = "?"
+	}
+	if problem.Line > 0 {
+		location += ":" + strconv.Itoa(problem.Line)
+	}
+	return location + ": " + problem.Message
+}
+
+// TemplateReport is the result of the validation of template files.
+type TemplateReport struct {
+	// Templates are the names of all the templates defined by the files, sorted.
+	Templates []string
+	// Problems are all the errors found, in the order of the files.
+	Problems []TemplateProblem
+}
+
+// OK returns true when no problem has been found.
+func (report *TemplateReport) OK() bool {
+	return len(report.Problems) == 0
+}
+
+// String returns the problems, one per line.
+func (report *TemplateReport) String() string {
+	buffer := new(bytes.Buffer)
+	for _, problem := range report.Problems {
+		fmt.Fprintln(buffer, problem)
+	}
+	return buffer.String()
+}
+
+// TemplateSample is binding data used to check that a template executes.
+type TemplateSample struct {
+	// Template to execute.
+	Template string
+	// Layout in which the template is executed, if any.
+	Layout string
+	// Binding of the template, usually decoded from JSON.
+	Binding interface{}
+}
+
+// Validate parses every template file, without stopping at the first error, and lists the defined templates.
+// Then the samples are executed with the templates that could be parsed; the fields and keys missing from
+// the binding are reported as errors.
+// It returns an error only when the files cannot be read.
+func (factory *FSTemplateFactory) Validate(samples ...TemplateSample) (*TemplateReport, error) {
+	report := &TemplateReport{}
+	files := make(map[string]string)
+	result := createTemplates(factory.rootName(), factory.Options)
+	result.Option("missingkey=error")
+
+	err := factory.walk(func(name string, file string, content []byte) error {
+		files[name] = file
+		if err := parseTemplate(result, name, string(content), factory.Options); err != nil {
+			report.Problems = append(report.Problems, templateProblem(err, files))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tmpl := range result.Templates() {
+		if tmpl.Name() != result.Name() && !strings.Contains(tmpl.Name(), scopeSeparator) {
+			report.Templates = append(report.Templates, tmpl.Name())
+		}
+	}
+	sort.Strings(report.Templates)
+
+	set := newTemplateSet(result)
+	for _, sample := range samples {
+		if err := set.execute(new(bytes.Buffer), sample.Layout, sample.Template, sample.Binding, nil); err != nil {
+			problem := templateProblem(err, files)
+			if problem.File == "" {
+				problem.File = files[sample.Template]
+			}
+			report.Problems = append(report.Problems, problem)
+		}
+	}
+	return report, nil
+}
+
+// Validate validates the template files of the directory, see FSTemplateFactory.Validate.
+// The files are reported with their path in the directory.
+func (factory *DiskTemplateFactory) Validate(samples ...TemplateSample) (*TemplateReport, error) {
+	return factory.fsFactory().Validate(samples...)
+}
+
+// templateLocation matches the location of the template errors, like "template: name:12: message",
+// "template: name:12:5: executing ..." or "html/template:name:12:5: message".
+var templateLocation = regexp.MustCompile(`^(?:html/)?template: ?([^:]+):(\d+):(?:\d+:)? ?(.*)$`)
+
+func templateProblem(err error, files map[string]string) TemplateProblem {
+	message := err.Error()
+	if matches := templateLocation.FindStringSubmatch(message); matches != nil {
+		if file, ok := files[matches[1]]; ok {
+			line, _ := strconv.Atoi(matches[2])
+			return TemplateProblem{File: file, Line: line, Message: matches[3]}
+		}
+	}
+	return TemplateProblem{Message: strings.TrimPrefix(message, "render: ")}
+}
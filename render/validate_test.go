@@ -0,0 +1,65 @@
+package render
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func Test_Validate_ShouldListTheDefinedTemplates(t *testing.T) {
+	report, err := NewFSTemplateFactory(fstest.MapFS{
+		"layout.tmpl":     {Data: []byte(`<title>{{block "title" .}}Site{{end}}</title>{{ yield }}`)},
+		"pages/home.tmpl": {Data: []byte(`{{define "title"}}Home{{end}}<p>home</p>`)},
+	}).Validate()
+	expect(t, err, nil)
+	expect(t, report.OK(), true)
+	expect(t, len(report.Templates), 3)
+	expect(t, report.Templates[0], "layout")
+	expect(t, report.Templates[1], "pages/home")
+	expect(t, report.Templates[2], "title")
+}
+
+func Test_Validate_ShouldReportAllTheParseErrorsWithTheirLocation(t *testing.T) {
+	report, _ := NewFSTemplateFactory(fstest.MapFS{
+		"first.tmpl":      {Data: []byte("line 1\n{{.}")},
+		"ok.tmpl":         {Data: []byte(`ok`)},
+		"second/bad.tmpl": {Data: []byte("line 1\nline 2\n{{if .}}")},
+	}).Validate()
+	expect(t, report.OK(), false)
+	expect(t, len(report.Problems), 2)
+	expect(t, report.Problems[0].File, "first.tmpl")
+	expect(t, report.Problems[0].Line, 2)
+	expect(t, report.Problems[1].File, "second/bad.tmpl")
+	expect(t, report.Problems[1].Line, 3)
+	expect(t, report.Problems[1].String(), "second/bad.tmpl:3: unexpected EOF")
+	expect(t, len(report.Templates), 1)
+	expect(t, report.Templates[0], "ok")
+}
+
+func Test_Validate_ShouldExecuteTheSamples(t *testing.T) {
+	factory := NewFSTemplateFactory(fstest.MapFS{
+		"layout.tmpl": {Data: []byte(`<html>{{ yield }}</html>`)},
+		"hello.tmpl":  {Data: []byte("<h1>\nHello {{.Name}}</h1>")},
+	})
+	report, _ := factory.Validate(
+		TemplateSample{Template: "hello", Binding: map[string]interface{}{"Name": "World"}},
+		TemplateSample{Template: "hello", Layout: "layout", Binding: map[string]interface{}{"Name": "World"}})
+	expect(t, report.OK(), true)
+
+	report, _ = factory.Validate(TemplateSample{Template: "hello", Binding: map[string]interface{}{"Title": "World"}})
+	expect(t, len(report.Problems), 1)
+	expect(t, report.Problems[0].File, "hello.tmpl")
+	expect(t, report.Problems[0].Line, 2)
+
+	report, _ = factory.Validate(TemplateSample{Template: "unknown"})
+	expect(t, len(report.Problems), 1)
+	expect(t, report.Problems[0].String(), `?: no template "unknown"`)
+}
+
+func Test_Validate_OnDisk_ShouldReportTheFiles(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{"pages/bad.tmpl": `{{.}`})
+	report, err := NewDiskTemplateFactory().SetDirectory(directory).Validate()
+	expect(t, err, nil)
+	expect(t, len(report.Problems), 1)
+	expect(t, report.Problems[0].File, "pages/bad.tmpl")
+	expect(t, report.Problems[0].Line, 1)
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TemplateWatcher notifies the changes of the template sources to a TemplateRender.
+// An implementation based on file system notifications, like fsnotify, can be used instead of the PollingWatcher.
+type TemplateWatcher interface {
+	// Changes returns a channel receiving a value after each change. It is closed by Close.
+	Changes() <-chan struct{}
+	// Close stops watching.
+	Close() error
+}
+
+// PollingWatcher detects the changes of the files of a directory by comparing, at regular intervals,
+// their names, sizes and modification times.
+type PollingWatcher struct {
+	fileSystem fs.FS
+	directory  string
+	changes    chan struct{}
+	done       chan struct{}
+	close      sync.Once
+}
+
+// NewPollingWatcher starts watching the directory of the file system every interval.
+func NewPollingWatcher(fileSystem fs.FS, directory string, interval time.Duration) *PollingWatcher {
+	watcher := &PollingWatcher{
+		fileSystem: fileSystem,
+		directory:  directory,
+		changes:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go watcher.poll(interval)
+	return watcher
+}
+
+func (watcher *PollingWatcher) Changes() <-chan struct{} {
+	return watcher.changes
+}
+
+func (watcher *PollingWatcher) Close() error {
+	watcher.close.Do(func() { close(watcher.done) })
+	return nil
+}
+
+func (watcher *PollingWatcher) poll(interval time.Duration) {
+	defer close(watcher.changes)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := watcher.signature()
+	for {
+		select {
+		case <-watcher.done:
+			return
+		case <-ticker.C:
+			if current := watcher.signature(); current != last {
+				last = current
+				select {
+				case watcher.changes <- struct{}{}:
+				default:
+					// A change is already pending.
+				}
+			}
+		}
+	}
+}
+
+// signature hashes the names, sizes and modification times of the files. Errors are part of the signature,
+// so that a directory becoming unreadable is also a change.
+func (watcher *PollingWatcher) signature() uint64 {
+	hash := fnv.New64a()
+	fs.WalkDir(watcher.fileSystem, watcher.directory, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(hash, "%s:%v\n", file, err)
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			fmt.Fprintf(hash, "%s:%v\n", file, err)
+			return nil
+		}
+		fmt.Fprintf(hash, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return hash.Sum64()
+}
+
+// renderCompilationError responds with an HTML page describing the compilation error of the templates.
+func renderCompilationError(writer http.ResponseWriter, err error) error {
+	page := "<!DOCTYPE html>\n<html><head><title>Template error</title></head><body>" +
+		"<h1>The templates could not be compiled</h1><pre>" + html.EscapeString(err.Error()) + "</pre>" +
+		"<p>The page will render again once the templates are fixed.</p></body></html>\n"
+	writeHeader(writer, http.StatusInternalServerError, "text/html", "UTF-8", OverrideContentType)
+	return write(writer, 0, []byte(page))
+}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Watch_ShouldRecompileTheTemplatesOnChange(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{"page.tmpl": `version 1`})
+	defer os.RemoveAll(directory)
+	watcher := newManualWatcher()
+	defer watcher.Close()
+	render := NewTemplateRender().SetFactory(NewDiskTemplateFactory().SetDirectory(directory)).Watch(watcher)
+
+	recorder, _ := renderTemplate(render, http.StatusOK, "page", nil)
+	expect(t, recorder.Body.String(), `version 1`)
+
+	writeFile(t, filepath.Join(directory, "page.tmpl"), `version 2`)
+	recorder, _ = renderTemplate(render, http.StatusOK, "page", nil)
+	expect(t, recorder.Body.String(), `version 1`)
+
+	watcher.changes <- struct{}{}
+	eventually(t, func() bool {
+		recorder, _ = renderTemplate(render, http.StatusOK, "page", nil)
+		return recorder.Body.String() == `version 2`
+	})
+}
+
+func Test_Watch_WithInvalidTemplate_ShouldRenderAnErrorPage(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{"page.tmpl": `<h1>{{.}</h1>`})
+	defer os.RemoveAll(directory)
+	watcher := newManualWatcher()
+	defer watcher.Close()
+	render := NewTemplateRender().SetFactory(NewDiskTemplateFactory().SetDirectory(directory)).Watch(watcher)
+
+	recorder, err := renderTemplate(render, http.StatusOK, "page", nil)
+	expect(t, err, nil)
+	expect(t, recorder.Code, http.StatusInternalServerError)
+	expect(t, recorder.Header().Get("Content-Type"), "text/html; charset=UTF-8")
+	expect(t, strings.Contains(recorder.Body.String(), `<pre>template: page:1: bad character U+007D &#39;}&#39;</pre>`), true)
+
+	writeFile(t, filepath.Join(directory, "page.tmpl"), `<h1>{{.}}</h1>`)
+	watcher.changes <- struct{}{}
+	eventually(t, func() bool {
+		recorder, _ = renderTemplate(render, http.StatusOK, "page", "fixed")
+		return recorder.Body.String() == `<h1>fixed</h1>`
+	})
+}
+
+func Test_Watch_WhileRendering_ShouldAlwaysRenderCompiledTemplates(t *testing.T) {
+	render := NewTemplateRender().SetFactory(NewStaticTemplateFactory("static").SetSubTemplates(func() (string, string) {
+		return "page", `page`
+	}))
+	watcher := newManualWatcher()
+	defer watcher.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			renderTemplate(render, http.StatusOK, "page", nil)
+		}
+	}()
+	render.Watch(watcher)
+	<-done
+	recorder, _ := renderTemplate(render, http.StatusOK, "page", nil)
+	expect(t, recorder.Body.String(), `page`)
+}
+
+func Test_PollingWatcher_ShouldNotifyTheChanges(t *testing.T) {
+	directory := writeTemplateFiles(t, map[string]string{"page.tmpl": `version 1`})
+	defer os.RemoveAll(directory)
+	watcher := NewPollingWatcher(os.DirFS(directory), ".", 10*time.Millisecond)
+	defer watcher.Close()
+
+	select {
+	case <-watcher.Changes():
+		t.Fatal("No change expected")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeFile(t, filepath.Join(directory, "other.tmpl"), `new file`)
+	select {
+	case <-watcher.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change expected")
+	}
+}
+
+func Test_PollingWatcher_Close_ShouldCloseTheChanges(t *testing.T) {
+	watcher := NewPollingWatcher(templateFS(), ".", time.Millisecond)
+	watcher.Close()
+	watcher.Close()
+	for range watcher.Changes() {
+	}
+}
+
+type manualWatcher struct {
+	changes chan struct{}
+}
+
+func newManualWatcher() *manualWatcher {
+	return &manualWatcher{changes: make(chan struct{})}
+}
+
+func (watcher *manualWatcher) Changes() <-chan struct{} {
+	return watcher.changes
+}
+
+func (watcher *manualWatcher) Close() error {
+	close(watcher.changes)
+	return nil
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func eventually(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
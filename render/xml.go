@@ -7,10 +7,13 @@ import (
 
 // XML built-in renderer.
 type XMLRender struct {
-	ContentType string
-	Charset     string
-	Indent      bool
-	Prefix      []byte
+	ContentType  string
+	Charset      string
+	HeaderPolicy HeaderPolicy
+	Indent       bool
+	Prefix       []byte
+	// If Stream is set to true, the value is encoded directly on the response instead of being marshalled first. Default is false.
+	Stream bool
 }
 
 func (render *XMLRender) SetContentType(value string) *XMLRender {
@@ -23,6 +26,11 @@ func (render *XMLRender) SetCharset(value string) *XMLRender {
 	return render
 }
 
+func (render *XMLRender) SetHeaderPolicy(value HeaderPolicy) *XMLRender {
+	render.HeaderPolicy = value
+	return render
+}
+
 func (render *XMLRender) SetIndent(value bool) *XMLRender {
 	render.Indent = value
 	return render
@@ -33,6 +41,11 @@ func (render *XMLRender) SetPrefix(prefix []byte) *XMLRender {
 	return render
 }
 
+func (render *XMLRender) SetStream(value bool) *XMLRender {
+	render.Stream = value
+	return render
+}
+
 // NewXMLRender creates a new XML render with default values.
 func NewXMLRender() *XMLRender {
 	return &XMLRender{
@@ -44,20 +57,36 @@ func NewXMLRender() *XMLRender {
 
 // Render an XML response.
 func (render *XMLRender) Render(writer http.ResponseWriter, status int, v interface{}) error {
+	if render.Stream {
+		return render.stream(writer, status, v)
+	}
+
 	result, err := marshallToXml(v, render.Indent)
 	if err != nil {
-		return err
+		return encodeError(err)
 	}
 
-	writeHeader(writer, status, render.ContentType, render.Charset)
-	if len(render.Prefix) > 0 {
-		writer.Write(render.Prefix)
+	writeHeader(writer, status, render.ContentType, render.Charset, render.HeaderPolicy)
+	return write(writer, len(render.Prefix), concat(render.Prefix, result, render.Indent))
+}
+
+// stream encodes the value directly on the response.
+// An encoding error may happen after a part of the document has been sent.
+func (render *XMLRender) stream(writer http.ResponseWriter, status int, v interface{}) error {
+	output := newHeaderWriter(writer, status, render.ContentType, render.Charset, render.HeaderPolicy, render.Prefix)
+	encoder := xml.NewEncoder(output)
+	if render.Indent {
+		encoder.Indent("", "  ")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return encodeError(err)
 	}
-	writer.Write(result)
 	if render.Indent {
-		writer.Write([]byte("\n"))
+		if _, err := output.Write([]byte("\n")); err != nil {
+			return err
+		}
 	}
-	return nil
+	return output.writeHeader()
 }
 
 func marshallToXml(v interface{}, indent bool) ([]byte, error) {
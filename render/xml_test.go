@@ -26,6 +26,20 @@ func Test_XML_Render_WhenContentTypeAlreadySet_ShouldDoNothing(t *testing.T) {
 	expect(t, recorder.Header().Get("Content-Type"), "bla")
 }
 
+func Test_XML_Render_WhenContentTypeAlreadySet_ShouldSetTheStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewXMLRender().Render(recorder, http.StatusNotFound, nil)
+	expect(t, recorder.Code, http.StatusNotFound)
+}
+
+func Test_XML_Render_WhenContentTypeAlreadySetWithOverridePolicy_ShouldReplaceTheContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "bla")
+	NewXMLRender().SetHeaderPolicy(OverrideContentType).Render(recorder, http.StatusOK, nil)
+	expect(t, recorder.Header().Get("Content-Type"), "text/xml; charset=UTF-8")
+}
+
 func Test_XML_Render_WhenNoContentType_ShouldSetTheContentType(t *testing.T) {
 	recorder, _ := renderXML(NewXMLRender(), http.StatusOK, nil)
 	expect(t, recorder.Header().Get("Content-Type"), "text/xml; charset=UTF-8")